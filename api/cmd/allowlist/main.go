@@ -0,0 +1,168 @@
+// allowlist builds the Merkle tree of a mint allowlist from a CSV of accounts.
+// It prints the root to pass to `SetMintAllowlistRoot` and the proof every account
+// passes to `MintAllowlisted`, as a JSON document.
+//
+// The first column of every row is a canonical account ID; a header row whose first column is "account" is skipped.
+// Hashing must match the chaincode: leaves are sha256(0x00 || account) and inner nodes
+// sha256(0x01 || lower child || higher child).
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// allowlistTree is the output of the command
+type allowlistTree struct {
+	Root   string              `json:"root"`
+	Proofs map[string][]string `json:"proofs"`
+}
+
+func main() {
+	csvPath := flag.String("csv", "", "CSV file listing one account per row")
+	outPath := flag.String("out", "", "file to write the root and proofs to, defaults to stdout")
+	flag.Parse()
+
+	if *csvPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	accounts, err := readAccounts(*csvPath)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", *csvPath, err)
+		os.Exit(1)
+	}
+	if len(accounts) == 0 {
+		fmt.Printf("Error reading %s: no accounts\n", *csvPath)
+		os.Exit(1)
+	}
+
+	tree := buildTree(accounts)
+
+	treeBytes, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding tree: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *outPath == "" {
+		fmt.Println(string(treeBytes))
+		return
+	}
+
+	err = os.WriteFile(*outPath, treeBytes, 0644)
+	if err != nil {
+		fmt.Printf("Error writing %s: %v\n", *outPath, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d proofs for root %s to %s\n", len(tree.Proofs), tree.Root, *outPath)
+}
+
+// readAccounts returns the distinct accounts of the first CSV column
+func readAccounts(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	seen := map[string]bool{}
+	var accounts []string
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		account := strings.TrimSpace(record[0])
+		if account == "" || (row == 1 && strings.EqualFold(account, "account")) {
+			continue
+		}
+
+		decoded, err := hex.DecodeString(account)
+		if err != nil || len(decoded) != 32 || strings.ToLower(account) != account {
+			return nil, fmt.Errorf("row %d: malformed account %s, expected 64 lowercase hex characters", row, account)
+		}
+
+		if !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+
+	return accounts, nil
+}
+
+// buildTree hashes the accounts into a tree whose levels pair neighbouring nodes,
+// an odd node out is carried up to the next level unchanged
+func buildTree(accounts []string) *allowlistTree {
+	type node struct {
+		hash     []byte
+		accounts []string
+	}
+
+	level := make([]node, len(accounts))
+	for index, account := range accounts {
+		level[index] = node{hash: leafHash(account), accounts: []string{account}}
+	}
+	sort.Slice(level, func(i, j int) bool { return bytes.Compare(level[i].hash, level[j].hash) < 0 })
+
+	proofs := map[string][]string{}
+	for _, account := range accounts {
+		proofs[account] = []string{}
+	}
+
+	for len(level) > 1 {
+		var next []node
+		for index := 0; index < len(level); index += 2 {
+			if index+1 == len(level) {
+				next = append(next, level[index])
+				continue
+			}
+
+			left, right := level[index], level[index+1]
+			for _, account := range left.accounts {
+				proofs[account] = append(proofs[account], hex.EncodeToString(right.hash))
+			}
+			for _, account := range right.accounts {
+				proofs[account] = append(proofs[account], hex.EncodeToString(left.hash))
+			}
+
+			accounts := append(append([]string{}, left.accounts...), right.accounts...)
+			next = append(next, node{hash: nodeHash(left.hash, right.hash), accounts: accounts})
+		}
+		level = next
+	}
+
+	return &allowlistTree{Root: hex.EncodeToString(level[0].hash), Proofs: proofs}
+}
+
+func leafHash(account string) []byte {
+	hash := sha256.Sum256(append([]byte{0x00}, account...))
+	return hash[:]
+}
+
+func nodeHash(a, b []byte) []byte {
+	if bytes.Compare(a, b) > 0 {
+		a, b = b, a
+	}
+
+	hash := sha256.Sum256(append(append([]byte{0x01}, a...), b...))
+	return hash[:]
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// The accounts are sha256("alice"), sha256("bob") and sha256("carol"). The chaincode verifies the same root
+// and proofs in TestVerifyAllowlistVectors of hyperledger_erc721/chaincode/model.
+const (
+	alice = "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90"
+	bob   = "81b637d8fcd2c6da6359e6963113a1170de795e4b725b84d1e0b4cfd9ec58ce9"
+	carol = "4c26d9074c27d89ede59270c0ac14b71e071b15239519f75474b2f3ba63481f5"
+)
+
+func TestBuildTree(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		want     *allowlistTree
+	}{
+		{
+			name:     "single account",
+			accounts: []string{alice},
+			want: &allowlistTree{
+				Root:   hex.EncodeToString(leafHash(alice)),
+				Proofs: map[string][]string{alice: {}},
+			},
+		},
+		{
+			name:     "odd node carried up",
+			accounts: []string{alice, bob, carol},
+			want: &allowlistTree{
+				Root: "70329f4164f88f7136b49e54140712fed16a3943f2ab9bd316cd8c7db0ff4291",
+				Proofs: map[string][]string{
+					alice: {"4888670d33fad94be0a2730569bb6912088e9ab3c20ddf66c9686682182a3d7c", "8ac791879a9df20949f8d71412b45b4b4d8262f38ab8e3e325ce59a184546027"},
+					bob:   {"561f5067a31d8316497758b4d7131a58c31dcdd367d653a3a615783f84e94753", "8ac791879a9df20949f8d71412b45b4b4d8262f38ab8e3e325ce59a184546027"},
+					carol: {"3620e4d34725376d3f2319c724bb0ffee2818b0de63b22203d5f7dcfac8e812c"},
+				},
+			},
+		},
+		{
+			name:     "input order",
+			accounts: []string{carol, bob, alice},
+			want: &allowlistTree{
+				Root: "70329f4164f88f7136b49e54140712fed16a3943f2ab9bd316cd8c7db0ff4291",
+				Proofs: map[string][]string{
+					alice: {"4888670d33fad94be0a2730569bb6912088e9ab3c20ddf66c9686682182a3d7c", "8ac791879a9df20949f8d71412b45b4b4d8262f38ab8e3e325ce59a184546027"},
+					bob:   {"561f5067a31d8316497758b4d7131a58c31dcdd367d653a3a615783f84e94753", "8ac791879a9df20949f8d71412b45b4b4d8262f38ab8e3e325ce59a184546027"},
+					carol: {"3620e4d34725376d3f2319c724bb0ffee2818b0de63b22203d5f7dcfac8e812c"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := buildTree(test.accounts); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("buildTree = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
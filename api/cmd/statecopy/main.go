@@ -0,0 +1,106 @@
+// statecopy copies the token state of the erc721 chaincode from one channel to another.
+// It pages through `ExportState` on the source channel and submits every page
+// to `ImportState` on the target channel, which must not have minted any token yet.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hyperledger_explorer/web"
+	"os"
+	"strconv"
+)
+
+// exportedPage is the part of a page returned by `ExportState` needed to fetch the next one
+type exportedPage struct {
+	Records  []json.RawMessage `json:"records"`
+	Bookmark string            `json:"bookmark"`
+}
+
+func main() {
+	cryptoPath := "../network/organizations/peerOrganizations/org1.example.com/"
+
+	sourceChannel := flag.String("from", "", "channel to export the token state from")
+	targetChannel := flag.String("to", "", "channel to import the token state into")
+	sourceChaincode := flag.String("chaincode", "token_erc721", "chaincode name on the source channel")
+	targetChaincode := flag.String("target-chaincode", "", "chaincode name on the target channel, defaults to -chaincode")
+	pageSize := flag.Int("page-size", 100, "records exported and imported per transaction")
+	flag.Parse()
+
+	if *sourceChannel == "" || *targetChannel == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *targetChaincode == "" {
+		*targetChaincode = *sourceChaincode
+	}
+
+	orgSetup, err := web.Initialize(web.OrgSetup{
+		OrgName:      "Org1",
+		MSPID:        "Org1MSP",
+		CertPath:     cryptoPath + "/users/User1@org1.example.com/msp/signcerts/User1@org1.example.com-cert.pem",
+		KeyPath:      cryptoPath + "/users/User1@org1.example.com/msp/keystore/",
+		TLSCertPath:  cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt",
+		PeerEndpoint: "localhost:7051",
+		GatewayPeer:  "peer0.org1.example.com",
+	})
+	if err != nil {
+		fmt.Printf("Error initializing setup for Org1: %v\n", err)
+		os.Exit(1)
+	}
+
+	source := orgSetup.Gateway.GetNetwork(*sourceChannel).GetContract(*sourceChaincode)
+	target := orgSetup.Gateway.GetNetwork(*targetChannel).GetContract(*targetChaincode)
+
+	total, err := copyState(source, target, *pageSize, func(total int) {
+		fmt.Printf("Imported %d records\n", total)
+	})
+	if err != nil {
+		fmt.Printf("Error copying state from %s to %s: %v\n", *sourceChannel, *targetChannel, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Copied %d records from %s to %s\n", total, *sourceChannel, *targetChannel)
+}
+
+// evaluator runs the queries of the source contract
+type evaluator interface {
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+}
+
+// submitter submits the transactions of the target contract
+type submitter interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+}
+
+// copyState pages through `ExportState` of source and imports every page into target as is,
+// calling imported with the running record count. It returns the number of records copied.
+func copyState(source evaluator, target submitter, pageSize int, imported func(total int)) (int, error) {
+	bookmark := ""
+	total := 0
+	for {
+		pageBytes, err := source.EvaluateTransaction("ExportState", strconv.Itoa(pageSize), bookmark)
+		if err != nil {
+			return total, fmt.Errorf("failed to export state: %w", err)
+		}
+
+		var page exportedPage
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return total, fmt.Errorf("failed to decode exported page: %w", err)
+		}
+
+		_, err = target.SubmitTransaction("ImportState", string(pageBytes))
+		if err != nil {
+			return total, fmt.Errorf("failed to import state after %d records: %w", total, err)
+		}
+
+		total += len(page.Records)
+		imported(total)
+
+		if page.Bookmark == "" {
+			return total, nil
+		}
+		bookmark = page.Bookmark
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// exportingContract serves ExportState pages of records, the bookmark being the index of the next record
+type exportingContract struct {
+	records   int
+	bookmarks []string
+}
+
+func (c *exportingContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	if name != "ExportState" || len(args) != 2 {
+		return nil, fmt.Errorf("unexpected %s%q", name, args)
+	}
+	c.bookmarks = append(c.bookmarks, args[1])
+
+	pageSize, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start := 0
+	if args[1] != "" {
+		start, err = strconv.Atoi(args[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page := exportedPage{Records: []json.RawMessage{}}
+	for index := start; index < c.records && index < start+pageSize; index++ {
+		page.Records = append(page.Records, json.RawMessage(strconv.Itoa(index)))
+	}
+	if start+pageSize < c.records {
+		page.Bookmark = strconv.Itoa(start + pageSize)
+	}
+
+	return json.Marshal(page)
+}
+
+// importingContract records the ImportState pages, failing from the page at failAt
+type importingContract struct {
+	pages  []string
+	failAt int
+}
+
+func (c *importingContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	if name != "ImportState" || len(args) != 1 {
+		return nil, fmt.Errorf("unexpected %s%q", name, args)
+	}
+	if c.failAt > 0 && len(c.pages)+1 == c.failAt {
+		return nil, errors.New("CONFLICT")
+	}
+	c.pages = append(c.pages, args[0])
+
+	return []byte("0"), nil
+}
+
+func TestCopyState(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   int
+		pageSize  int
+		failAt    int
+		bookmarks []string
+		progress  []int
+		wantErr   bool
+	}{
+		{"empty state", 0, 2, 0, []string{""}, []int{0}, false},
+		{"single page", 2, 5, 0, []string{""}, []int{2}, false},
+		{"pages", 5, 2, 0, []string{"", "2", "4"}, []int{2, 4, 5}, false},
+		{"full last page", 4, 2, 0, []string{"", "2"}, []int{2, 4}, false},
+		{"import error", 5, 2, 2, []string{"", "2"}, []int{2}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := &exportingContract{records: test.records}
+			target := &importingContract{failAt: test.failAt}
+			progress := []int{}
+
+			total, err := copyState(source, target, test.pageSize, func(total int) {
+				progress = append(progress, total)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("copyState error %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(source.bookmarks, test.bookmarks) {
+				t.Fatalf("bookmarks %q, want %q", source.bookmarks, test.bookmarks)
+			}
+			if !reflect.DeepEqual(progress, test.progress) {
+				t.Fatalf("progress %v, want %v", progress, test.progress)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if total != test.records || len(target.pages) != len(test.bookmarks) {
+				t.Fatalf("%d records in %d pages, want %d in %d", total, len(target.pages), test.records, len(test.bookmarks))
+			}
+			// Every page is imported exactly as exported
+			for index, pageJSON := range target.pages {
+				page := exportedPage{}
+				if err := json.Unmarshal([]byte(pageJSON), &page); err != nil {
+					t.Fatal(err)
+				}
+				if index+1 < len(target.pages) && page.Bookmark != test.bookmarks[index+1] {
+					t.Fatalf("page %d bookmark %q, want %q", index, page.Bookmark, test.bookmarks[index+1])
+				}
+			}
+		})
+	}
+}
@@ -4,12 +4,12 @@ go 1.19
 
 require (
 	github.com/hyperledger/fabric-gateway v1.1.2
+	github.com/hyperledger/fabric-protos-go-apiv2 v0.2.0
 	google.golang.org/grpc v1.51.0
 )
 
 require (
 	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/hyperledger/fabric-protos-go-apiv2 v0.2.0 // indirect
 	github.com/miekg/pkcs11 v1.1.1 // indirect
 	golang.org/x/net v0.4.0 // indirect
 	golang.org/x/sys v0.3.0 // indirect
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"hyperledger_explorer/web"
+	"os"
 )
 
 var (
@@ -21,6 +22,11 @@ func init() {
 		PeerEndpoint: "localhost:7051",
 		GatewayPeer:  "peer0.org1.example.com",
 	}
+
+	orgConfig.ContentResolver = os.Getenv("CONTENT_RESOLVER")
+	if orgConfig.ContentResolver == "" {
+		orgConfig.ContentResolver = "https://ipfs.io/ipfs/"
+	}
 }
 
 func main() {
@@ -16,12 +16,15 @@ type OrgSetup struct {
 	TLSCertPath  string
 	PeerEndpoint string
 	GatewayPeer  string
-	Gateway      client.Gateway
+	// ContentResolver is the gateway URL `ipfs://` token URIs are fetched through
+	ContentResolver string
+	Gateway         client.Gateway
 }
 
 func Serve(setups OrgSetup) {
 	http.HandleFunc("/query", setups.Query)
 	http.HandleFunc("/invoke", setups.Invoke)
+	http.HandleFunc("/verify", setups.Verify)
 	fmt.Println("Listening (http://localhost:3000/)...")
 	if err := http.ListenAndServe(":3000", nil); err != nil {
 		fmt.Println(err)
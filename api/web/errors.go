@@ -0,0 +1,86 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"google.golang.org/grpc/status"
+)
+
+// ChaincodeError mirrors the structured error returned by the erc721 chaincode
+type ChaincodeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Code used when the failure did not come from the chaincode, e.g. the gateway was unreachable
+const gatewayErrorCode = "GATEWAY_ERROR"
+
+// HTTP status returned for each chaincode error code
+var chaincodeErrorStatus = map[string]int{
+	"NOT_INITIALIZED":      http.StatusPreconditionFailed,
+	"COLLECTION_NOT_FOUND": http.StatusNotFound,
+	"TOKEN_NOT_FOUND":      http.StatusNotFound,
+	"PROPOSAL_NOT_FOUND":   http.StatusNotFound,
+	"RECOVERY_NOT_FOUND":   http.StatusNotFound,
+	"REQUEST_NOT_FOUND":    http.StatusNotFound,
+	"UNAUTHORIZED":         http.StatusForbidden,
+	"ALREADY_MINTED":       http.StatusConflict,
+	"ALREADY_EXISTS":       http.StatusConflict,
+	"PAUSED":               http.StatusServiceUnavailable,
+	"MINT_LIMIT_REACHED":   http.StatusConflict,
+	"FROZEN":               http.StatusLocked,
+	"SCHEMA_VIOLATION":     http.StatusUnprocessableEntity,
+	"INVALID_ARGUMENT":     http.StatusBadRequest,
+	"CONFLICT":             http.StatusConflict,
+	"INSUFFICIENT_SHARES":  http.StatusConflict,
+	"INTERNAL":             http.StatusInternalServerError,
+}
+
+// parseChaincodeError finds the chaincode error in the gateway error message or its endorser details
+func parseChaincodeError(err error) *ChaincodeError {
+	messages := []string{err.Error()}
+	if grpcStatus, ok := status.FromError(err); ok {
+		for _, detail := range grpcStatus.Details() {
+			if errorDetail, ok := detail.(*gateway.ErrorDetail); ok {
+				messages = append(messages, errorDetail.GetMessage())
+			}
+		}
+	}
+
+	for _, message := range messages {
+		start := strings.Index(message, `{"code":`)
+		if start < 0 {
+			continue
+		}
+
+		chaincodeErr := &ChaincodeError{}
+		if json.NewDecoder(strings.NewReader(message[start:])).Decode(chaincodeErr) == nil {
+			return chaincodeErr
+		}
+	}
+
+	return nil
+}
+
+// writeError responds with the chaincode error and the HTTP status mapped from its code
+func writeError(w http.ResponseWriter, context string, err error) {
+	httpStatus := http.StatusBadGateway
+	chaincodeErr := parseChaincodeError(err)
+
+	if chaincodeErr == nil {
+		chaincodeErr = &ChaincodeError{Code: gatewayErrorCode, Message: fmt.Sprintf("%s: %s", context, err)}
+	} else if mapped, ok := chaincodeErrorStatus[chaincodeErr.Code]; ok {
+		httpStatus = mapped
+	} else {
+		httpStatus = http.StatusInternalServerError
+	}
+
+	fmt.Printf("%s: %s\n", context, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(chaincodeErr)
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", errors.New(`chaincode response 500, {"code":"TOKEN_NOT_FOUND","message":"non-fungible token 1 does not exist"}`), http.StatusNotFound, "TOKEN_NOT_FOUND"},
+		{"unauthorized", errors.New(`{"code":"UNAUTHORIZED","message":"not authorized"}`), http.StatusForbidden, "UNAUTHORIZED"},
+		{"frozen", errors.New(`{"code":"FROZEN","message":"the account a is frozen"}`), http.StatusLocked, "FROZEN"},
+		{"schema violation", errors.New(`{"code":"SCHEMA_VIOLATION","message":"color is required"}`), http.StatusUnprocessableEntity, "SCHEMA_VIOLATION"},
+		{"unknown code", errors.New(`{"code":"NEW_CODE","message":"added later"}`), http.StatusInternalServerError, "NEW_CODE"},
+		{"gateway failure", errors.New("connection refused"), http.StatusBadGateway, gatewayErrorCode},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			writeError(recorder, "failed to submit", test.err)
+
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("status %d, want %d", recorder.Code, test.wantStatus)
+			}
+
+			chaincodeErr := &ChaincodeError{}
+			if err := json.NewDecoder(recorder.Body).Decode(chaincodeErr); err != nil {
+				t.Fatal(err)
+			}
+			if chaincodeErr.Code != test.wantCode {
+				t.Fatalf("code %s, want %s", chaincodeErr.Code, test.wantCode)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ type InvokeBody struct {
 func (setup *OrgSetup) Invoke(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Received Invoke request")
 	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(w, "ParseForm() err: %s", err)
 		return
 	}
@@ -39,19 +40,24 @@ func (setup *OrgSetup) Invoke(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("channel: %s, chaincode: %s, function: %s, args: %s\n", channelID, chainCodeName, function, args)
 	network := setup.Gateway.GetNetwork(channelID)
 	contract := network.GetContract(chainCodeName)
-	txn_proposal, err := contract.NewProposal(function, client.WithArguments(args...))
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	// Retries carrying the same request ID return the original result instead of running twice
+	if requestID := r.FormValue("requestid"); requestID != "" {
+		options = append(options, client.WithTransient(map[string][]byte{"requestId": []byte(requestID)}))
+	}
+	txn_proposal, err := contract.NewProposal(function, options...)
 	if err != nil {
-		fmt.Fprintf(w, "Error creating txn proposal: %s", err)
+		writeError(w, "Error creating txn proposal", err)
 		return
 	}
 	txn_endorsed, err := txn_proposal.Endorse()
 	if err != nil {
-		fmt.Fprintf(w, "Error endorsing txn: %s", err)
+		writeError(w, "Error endorsing txn", err)
 		return
 	}
 	txn_committed, err := txn_endorsed.Submit()
 	if err != nil {
-		fmt.Fprintf(w, "Error submitting transaction: %s", err)
+		writeError(w, "Error submitting transaction", err)
 		return
 	}
 	fmt.Fprintf(w, "Transaction ID : %s Response: %s", txn_committed.TransactionID(), txn_endorsed.Result())
@@ -18,7 +18,7 @@ func (setup OrgSetup) Query(w http.ResponseWriter, r *http.Request) {
 	contract := network.GetContract(chainCodeName)
 	evaluateResponse, err := contract.EvaluateTransaction(function, args...)
 	if err != nil {
-		fmt.Fprintf(w, "Error: %s", err)
+		writeError(w, "Error", err)
 		return
 	}
 	fmt.Fprintf(w, "Response: %s", evaluateResponse)
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Code reported when the content behind a token URI could not be fetched
+const contentErrorCode = "CONTENT_UNAVAILABLE"
+
+// Largest metadata document fetched for verification
+const maxContentBytes = 10 << 20
+
+// contentClient fetches through the configured content resolver, which may be a gateway on the local network
+var contentClient = &http.Client{Timeout: 10 * time.Second}
+
+// publicContentClient fetches the HTTP token URIs minters choose. It only connects to public addresses,
+// also after redirects, so a token URI cannot make the server reach itself or the internal network.
+var publicContentClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: rejectNonPublicAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// ContentVerification is the response of the verify handler
+type ContentVerification struct {
+	TokenId     string `json:"tokenId"`
+	TokenURI    string `json:"tokenURI"`
+	ResolvedURL string `json:"resolvedURL"`
+	ContentHash string `json:"contentHash"`
+	Matches     bool   `json:"matches"`
+}
+
+// Verify fetches the live content of a token URI and checks it against the content hash anchored on the ledger.
+func (setup OrgSetup) Verify(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received Verify request")
+	queryParams := r.URL.Query()
+	chainCodeName := queryParams.Get("chaincodeid")
+	channelID := queryParams.Get("channelid")
+	collectionID := queryParams.Get("collectionid")
+	tokenID := queryParams.Get("tokenid")
+	fmt.Printf("channel: %s, chaincode: %s, collection: %s, token: %s\n", channelID, chainCodeName, collectionID, tokenID)
+	network := setup.Gateway.GetNetwork(channelID)
+	contract := network.GetContract(chainCodeName)
+
+	var tokenURI []byte
+	var err error
+	if collectionID == "" {
+		tokenURI, err = contract.EvaluateTransaction("TokenURI", tokenID)
+	} else {
+		tokenURI, err = contract.EvaluateTransaction("CollectionTokenURI", collectionID, tokenID)
+	}
+	if err != nil {
+		writeError(w, "Error reading token URI", err)
+		return
+	}
+
+	resolvedURL, err := setup.resolveContentURL(string(tokenURI))
+	if err != nil {
+		writeContentError(w, err)
+		return
+	}
+
+	client := publicContentClient
+	if strings.HasPrefix(string(tokenURI), "ipfs://") {
+		client = contentClient
+	}
+
+	content, err := fetchContent(client, resolvedURL)
+	if err != nil {
+		writeContentError(w, err)
+		return
+	}
+
+	digest := sha256.Sum256(content)
+	contentHash := "sha256:" + hex.EncodeToString(digest[:])
+
+	var matches []byte
+	if collectionID == "" {
+		matches, err = contract.EvaluateTransaction("VerifyContent", tokenID, contentHash)
+	} else {
+		matches, err = contract.EvaluateTransaction("CollectionVerifyContent", collectionID, tokenID, contentHash)
+	}
+	if err != nil {
+		writeError(w, "Error verifying content", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&ContentVerification{
+		TokenId:     tokenID,
+		TokenURI:    string(tokenURI),
+		ResolvedURL: resolvedURL,
+		ContentHash: contentHash,
+		Matches:     string(matches) == "true",
+	})
+}
+
+// resolveContentURL maps `ipfs://` URIs onto the configured resolver, HTTP URIs are fetched as they are
+func (setup OrgSetup) resolveContentURL(tokenURI string) (string, error) {
+	switch {
+	case strings.HasPrefix(tokenURI, "ipfs://"):
+		if setup.ContentResolver == "" {
+			return "", fmt.Errorf("no content resolver configured for %s", tokenURI)
+		}
+		return strings.TrimSuffix(setup.ContentResolver, "/") + "/" + strings.TrimPrefix(tokenURI, "ipfs://"), nil
+	case strings.HasPrefix(tokenURI, "https://"), strings.HasPrefix(tokenURI, "http://"):
+		return tokenURI, nil
+	default:
+		return "", fmt.Errorf("unsupported token URI %q", tokenURI)
+	}
+}
+
+func fetchContent(client *http.Client, url string) ([]byte, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned %s", url, response.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxContentBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxContentBytes {
+		return nil, fmt.Errorf("content of %s exceeds %d bytes", url, maxContentBytes)
+	}
+
+	return content, nil
+}
+
+// rejectNonPublicAddress refuses connections to loopback, link-local, private and unspecified addresses
+func rejectNonPublicAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("refusing to connect to %s", address)
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to connect to non-public address %s", address)
+	}
+
+	return nil
+}
+
+func writeContentError(w http.ResponseWriter, err error) {
+	fmt.Printf("Error fetching content: %s\n", err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadGateway)
+	json.NewEncoder(w).Encode(&ChaincodeError{Code: contentErrorCode, Message: err.Error()})
+}
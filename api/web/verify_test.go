@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveContentURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver string
+		tokenURI string
+		want     string
+		wantErr  bool
+	}{
+		{"ipfs", "https://ipfs.io/ipfs/", "ipfs://bafy/1.json", "https://ipfs.io/ipfs/bafy/1.json", false},
+		{"ipfs resolver without slash", "http://localhost:8080/ipfs", "ipfs://bafy/1.json", "http://localhost:8080/ipfs/bafy/1.json", false},
+		{"ipfs without resolver", "", "ipfs://bafy/1.json", "", true},
+		{"https", "", "https://example.com/nft/1.json", "https://example.com/nft/1.json", false},
+		{"http", "", "http://example.com/nft/1.json", "http://example.com/nft/1.json", false},
+		{"unsupported scheme", "https://ipfs.io/ipfs/", "ar://abc", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := OrgSetup{ContentResolver: test.resolver}.resolveContentURL(test.tokenURI)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error %v, want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("resolved %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFetchContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1.json":
+			w.Write([]byte(`{"name":"deed 1"}`))
+		case "/large.json":
+			w.Write([]byte(strings.Repeat("a", maxContentBytes+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"document", "/1.json", `{"name":"deed 1"}`, false},
+		{"missing", "/2.json", "", true},
+		{"too large", "/large.json", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, err := fetchContent(contentClient, server.URL+test.path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error %v, want error %v", err, test.wantErr)
+			}
+			if string(content) != test.want {
+				t.Fatalf("content %q, want %q", content, test.want)
+			}
+		})
+	}
+}
+
+func TestFetchPublicContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"deed 1"}`))
+	}))
+	defer server.Close()
+
+	// A token URI must not reach the server itself or the internal network
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"loopback", server.URL + "/1.json"},
+		{"localhost", strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/1.json"},
+		{"unspecified", "http://0.0.0.0:1/1.json"},
+		{"link-local", "http://169.254.169.254/latest/meta-data/"},
+		{"private", "http://10.0.0.1/1.json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := fetchContent(publicContentClient, test.url)
+			if err == nil || !strings.Contains(err.Error(), "refusing to connect") {
+				t.Fatalf("error %v, want a refused connection", err)
+			}
+		})
+	}
+}
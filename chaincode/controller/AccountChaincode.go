@@ -0,0 +1,182 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Reserved addresses that can never receive a token through a transfer
+var reservedAddresses = map[string]bool{
+	ZeroAddress:          true,
+	FractionVaultAddress: true,
+}
+
+/*
+`SetRecipientRegistryRequired` is invoke fnc that makes transfers accept only recipients
+registered in the known-accounts registry
+*/
+func (c *TokenERC721Contract) SetRecipientRegistryRequired(ctx contractapi.TransactionContextInterface, required bool) (bool, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.RequireRegisteredRecipients = required
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`RegisterAccount` is invoke fnc that adds an account to the known-accounts registry
+*/
+func (c *TokenERC721Contract) RegisterAccount(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+
+	err := validateAccountFormat(account)
+	if err != nil {
+		return false, err
+	}
+
+	err = _putAccountRegistration(ctx, account, true)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`RegisterClientAccount` is invoke fnc that adds the canonical account of the requesting admin to the known-accounts registry.
+Like `RegisterAccount` it is restricted to admins, so the registry only holds accounts an admin has vouched for.
+*/
+func (c *TokenERC721Contract) RegisterClientAccount(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = _putAccountRegistration(ctx, client.ID, true)
+	if err != nil {
+		return "", err
+	}
+
+	return client.ID, nil
+}
+
+/*
+`UnregisterAccount` is invoke fnc that removes an account from the known-accounts registry
+*/
+func (c *TokenERC721Contract) UnregisterAccount(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+
+	err := _putAccountRegistration(ctx, account, false)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`IsRegisteredAccount` is query fnc that checks whether an account is in the known-accounts registry
+*/
+func (c *TokenERC721Contract) IsRegisteredAccount(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	return _isRegisteredAccount(ctx, account)
+}
+
+// _validateRecipient rejects empty, reserved and malformed recipients,
+// and recipients missing from the registry when the contract requires registration
+func _validateRecipient(ctx contractapi.TransactionContextInterface, to string) error {
+	if reservedAddresses[to] {
+		return invalidArgumentError("recipient %s is a reserved address", to)
+	}
+
+	err := validateAccountFormat(to)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return err
+	}
+	if !metadata.RequireRegisteredRecipients {
+		return nil
+	}
+
+	registered, err := _isRegisteredAccount(ctx, to)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return invalidArgumentError("recipient %s is not a registered account", to)
+	}
+
+	return nil
+}
+
+// validateAccountFormat accepts canonical account IDs and, during the migration period,
+// legacy `x509::<subject>::<issuer>` IDs
+func validateAccountFormat(account string) error {
+	if account == "" {
+		return invalidArgumentError("account must not be empty")
+	}
+
+	if strings.HasPrefix(account, "x509::") {
+		parts := strings.Split(account, "::")
+		if len(parts) != 3 || !strings.Contains(parts[1], "CN=") || parts[2] == "" {
+			return invalidArgumentError("malformed legacy account %s", account)
+		}
+		return nil
+	}
+
+	decoded, err := hex.DecodeString(account)
+	if err != nil || len(decoded) != 32 || strings.ToLower(account) != account {
+		return invalidArgumentError("malformed account %s, expected 64 lowercase hex characters", account)
+	}
+
+	return nil
+}
+
+func _isRegisteredAccount(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountPrefix, []string{account})
+	if err != nil {
+		return false, internalError("failed to CreateCompositeKey accountKey: %v", err)
+	}
+
+	accountBytes, err := _repository(ctx).getState(accountKey)
+	if err != nil {
+		return false, err
+	}
+
+	return len(accountBytes) > 0, nil
+}
+
+func _putAccountRegistration(ctx contractapi.TransactionContextInterface, account string, registered bool) error {
+	accountKey, err := ctx.GetStub().CreateCompositeKey(accountPrefix, []string{account})
+	if err != nil {
+		return internalError("failed to CreateCompositeKey accountKey: %v", err)
+	}
+
+	if !registered {
+		err = _repository(ctx).delState(accountKey)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = _repository(ctx).putState(accountKey, []byte{0})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package chaincode
+
+import "testing"
+
+func TestTransferRecipientValidation(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	adminID := ledger.account(admin)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+
+	tests := []struct {
+		name string
+		to   string
+	}{
+		{"empty", ""},
+		{"zero address", ZeroAddress},
+		{"fraction vault", FractionVaultAddress},
+		{"not hex", "not-an-account"},
+		{"upper case hex", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+		{"short hex", "abcdef"},
+		{"legacy without CN", "x509::O=org::CN=ca"},
+		{"legacy without issuer", "x509::CN=bob::"},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(admin, "TransferFrom", string(ErrCodeInvalidArgument), adminID, test.to, "1")
+		})
+	}
+
+	ledger.ok(admin, "TransferFrom", adminID, "x509::CN=bob::CN=bob", "1")
+}
+
+func TestRecipientRegistry(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "SetRecipientRegistryRequired", "true")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"register by non-admin", bob, "RegisterAccount", []string{bobID}, ErrCodeUnauthorized},
+		{"self-register by non-admin", bob, "RegisterClientAccount", nil, ErrCodeUnauthorized},
+		{"register malformed account", admin, "RegisterAccount", []string{"bob"}, ErrCodeInvalidArgument},
+		{"transfer to unregistered", admin, "TransferFrom", []string{adminID, bobID, "1"}, ErrCodeInvalidArgument},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	ledger.expect(admin, adminID, "RegisterClientAccount")
+	ledger.ok(admin, "RegisterAccount", bobID)
+	ledger.expect(admin, "true", "IsRegisteredAccount", bobID)
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "1")
+
+	ledger.ok(admin, "UnregisterAccount", bobID)
+	ledger.fail(bob, "TransferFrom", string(ErrCodeInvalidArgument), bobID, bobID, "1")
+	ledger.ok(bob, "TransferFrom", bobID, adminID, "1")
+}
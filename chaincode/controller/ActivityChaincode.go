@@ -0,0 +1,230 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_erc721/chaincode/model"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`GetAccountActivity` is query fnc that returns a page of at most pageSize mints, transfers and burns of an account,
+oldest first, between fromTime and toTime in Unix seconds (both included, toTime 0 for no end).
+Pass the returned bookmark to fetch the next page until it comes back empty.
+*/
+func (c *TokenERC721Contract) GetAccountActivity(ctx contractapi.TransactionContextInterface, account string, fromTime int64, toTime int64, pageSize int, bookmark string) (*model.ActivityPage, error) {
+
+	if account == "" {
+		return nil, invalidArgumentError("account must not be empty")
+	}
+	if pageSize < 1 {
+		return nil, invalidArgumentError("pageSize must be a positive number")
+	}
+	if fromTime < 0 || (toTime != 0 && toTime < fromTime) {
+		return nil, invalidArgumentError("malformed time range %d to %d", fromTime, toTime)
+	}
+
+	// Activity of a linked legacy ID is indexed under its canonical ID
+	aliases, err := _accountAliases(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	account = aliases[len(aliases)-1]
+
+	// The scan starts at fromTime and ends before the first second after toTime
+	startKey, err := _activityKey(ctx, account, _activityTime(fromTime))
+	if err != nil {
+		return nil, err
+	}
+	endKey, err := _activityKey(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	if toTime == 0 {
+		endKey += string(utf8.MaxRune)
+	} else {
+		endKey, err = _activityKey(ctx, account, _activityTime(toTime+1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	iterator, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination(startKey, endKey, int32(pageSize), bookmark)
+	if err != nil {
+		return nil, internalError("failed to GetStateByRangeWithPagination %s: %v", activityPrefix, err)
+	}
+	defer iterator.Close()
+
+	page := model.NewActivityPage()
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, internalError("failed to iterate %s: %v", activityPrefix, err)
+		}
+
+		activity := new(model.Activity)
+		err = json.Unmarshal(kv.Value, activity)
+		if err != nil {
+			return nil, internalError("failed to unmarshal activityBytes: %v", err)
+		}
+
+		page.Records = append(page.Records, activity)
+	}
+
+	// CouchDB returns a bookmark with the last page as well
+	if len(page.Records) == pageSize {
+		page.Bookmark = responseMetadata.GetBookmark()
+	}
+
+	return page, nil
+}
+
+// _recordActivity appends a transfer to the activity index of its sender and recipient.
+// The zero address and the fraction vault are not accounts and get no entries.
+func _recordActivity(ctx contractapi.TransactionContextInterface, transfer *model.Transfer) error {
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	txId := ctx.GetStub().GetTxID()
+
+	var accounts []string
+	for _, account := range []string{transfer.From, transfer.To} {
+		if account == ZeroAddress || account == FractionVaultAddress {
+			continue
+		}
+
+		account, err = _activityAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		// A transfer to oneself is recorded once
+		if len(accounts) == 0 || accounts[0] != account {
+			accounts = append(accounts, account)
+		}
+	}
+
+	for _, account := range accounts {
+		activityKey, err := _activityKey(ctx, account, _activityTime(now), txId, transfer.CollectionId, transfer.TokenId)
+		if err != nil {
+			return err
+		}
+
+		activityBytes, err := json.Marshal(&model.Activity{
+			Account:      account,
+			CollectionId: transfer.CollectionId,
+			TokenId:      transfer.TokenId,
+			From:         transfer.From,
+			To:           transfer.To,
+			TxId:         txId,
+			Timestamp:    now,
+		})
+		if err != nil {
+			return internalError("failed to marshal activityBytes: %v", err)
+		}
+
+		err = _repository(ctx).putState(activityKey, activityBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// _moveActivity indexes the activity of account under target instead
+func _moveActivity(ctx contractapi.TransactionContextInterface, account string, target string) error {
+	startKey, err := _activityKey(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	iterator, err := ctx.GetStub().GetStateByRange(startKey, startKey+string(utf8.MaxRune))
+	if err != nil {
+		return internalError("failed to GetStateByRange %s: %v", activityPrefix, err)
+	}
+	defer iterator.Close()
+
+	repository := _repository(ctx)
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return internalError("failed to iterate %s: %v", activityPrefix, err)
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey("\x00" + kv.Key)
+		if err != nil {
+			return internalError("failed to SplitCompositeKey %s: %v", kv.Key, err)
+		}
+
+		activity := new(model.Activity)
+		err = json.Unmarshal(kv.Value, activity)
+		if err != nil {
+			return internalError("failed to unmarshal activityBytes: %v", err)
+		}
+		activity.Account = target
+
+		activityBytes, err := json.Marshal(activity)
+		if err != nil {
+			return internalError("failed to marshal activityBytes: %v", err)
+		}
+
+		activityKey, err := _activityKey(ctx, append([]string{target}, attributes[1:]...)...)
+		if err != nil {
+			return err
+		}
+
+		err = repository.putState(activityKey, activityBytes)
+		if err != nil {
+			return err
+		}
+
+		err = repository.delState(kv.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// _activityAccount returns the account activity is indexed under: the canonical ID of the client
+// or the one recorded for a legacy ID, so the activity of both formats is found under the canonical account
+func _activityAccount(ctx contractapi.TransactionContextInterface, account string) (string, error) {
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+	if client.Is(account) {
+		return client.ID, nil
+	}
+
+	aliases, err := _accountAliases(ctx, account)
+	if err != nil {
+		return "", err
+	}
+
+	return aliases[len(aliases)-1], nil
+}
+
+// _activityKey returns the key of the activity index for the attributes.
+// It is the composite key without its leading null byte, a simple key, as range queries reject composite keys.
+func _activityKey(ctx contractapi.TransactionContextInterface, attributes ...string) (string, error) {
+	compositeKey, err := ctx.GetStub().CreateCompositeKey(activityPrefix, attributes)
+	if err != nil {
+		return "", internalError("failed to CreateCompositeKey activityKey: %v", err)
+	}
+
+	return compositeKey[1:], nil
+}
+
+// _activityTime pads a timestamp so activity keys sort in time order
+func _activityTime(timestamp int64) string {
+	return fmt.Sprintf("%020d", timestamp)
+}
@@ -0,0 +1,139 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_erc721/chaincode/model"
+	"reflect"
+	"testing"
+)
+
+// activityPages follows the bookmarks of GetAccountActivity and returns the timestamps of every page
+func activityPages(ledger *testLedger, client testClient, account string, fromTime int64, toTime int64, pageSize int) [][]int64 {
+	ledger.t.Helper()
+
+	pages := [][]int64{}
+	bookmark := ""
+	for {
+		page := model.NewActivityPage()
+		pageJSON := ledger.ok(client, "GetAccountActivity", account, fmt.Sprint(fromTime), fmt.Sprint(toTime), fmt.Sprint(pageSize), bookmark)
+		if err := json.Unmarshal([]byte(pageJSON), page); err != nil {
+			ledger.t.Fatal(err)
+		}
+
+		timestamps := []int64{}
+		for _, activity := range page.Records {
+			if activity.Account != account {
+				ledger.t.Fatalf("activity of %s in the page of %s", activity.Account, account)
+			}
+			timestamps = append(timestamps, activity.Timestamp)
+		}
+		pages = append(pages, timestamps)
+
+		if page.Bookmark == "" {
+			return pages
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+func TestGetAccountActivity(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	for i, tokenId := range []string{"1", "2", "3", "4"} {
+		ledger.now = int64(100 * (i + 1))
+		ledger.ok(admin, "MintWithTokenURI", tokenId, "ipfs://deed/"+tokenId)
+	}
+	ledger.now = 500
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "1")
+	ledger.now = 600
+	ledger.ok(bob, "Burn", "1")
+
+	tests := []struct {
+		name     string
+		couchDB  bool
+		account  string
+		fromTime int64
+		toTime   int64
+		pageSize int
+		want     [][]int64
+	}{
+		{"single page", false, adminID, 0, 0, 10, [][]int64{{100, 200, 300, 400, 500}}},
+		{"pages", false, adminID, 0, 0, 2, [][]int64{{100, 200}, {300, 400}, {500}}},
+		{"full last page", false, adminID, 0, 0, 5, [][]int64{{100, 200, 300, 400, 500}}},
+		{"from time", false, adminID, 250, 0, 2, [][]int64{{300, 400}, {500}}},
+		{"from time of an entry", false, adminID, 300, 0, 2, [][]int64{{300, 400}, {500}}},
+		{"to time", false, adminID, 0, 300, 2, [][]int64{{100, 200}, {300}}},
+		{"to time at a page end", false, adminID, 0, 200, 2, [][]int64{{100, 200}}},
+		{"time range", false, adminID, 200, 400, 1, [][]int64{{200}, {300}, {400}}},
+		{"recipient", false, bobID, 0, 0, 1, [][]int64{{500}, {600}}},
+		{"no activity", false, bobID, 700, 0, 10, [][]int64{{}}},
+		{"couchdb pages", true, adminID, 0, 0, 2, [][]int64{{100, 200}, {300, 400}, {500}}},
+		{"couchdb full last page", true, adminID, 0, 0, 5, [][]int64{{100, 200, 300, 400, 500}, {}}},
+		{"couchdb time range", true, adminID, 200, 400, 2, [][]int64{{200, 300}, {400}}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.stub.couchDB = test.couchDB
+			defer func() { ledger.stub.couchDB = false }()
+
+			got := activityPages(ledger, bob, test.account, test.fromTime, test.toTime, test.pageSize)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("pages %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// A key of the activity index is not a ledger bookmark
+	activityKey := activityPrefix + "\x00" + adminID + "\x00" + _activityTime(300) + "\x00"
+	ledger.fail(bob, "GetAccountActivity", "bookmark", adminID, "0", "0", "2", activityKey)
+	ledger.fail(bob, "GetAccountActivity", string(ErrCodeInvalidArgument), adminID, "0", "0", "0", "")
+	ledger.fail(bob, "GetAccountActivity", string(ErrCodeInvalidArgument), adminID, "300", "200", "2", "")
+	ledger.fail(bob, "GetAccountActivity", string(ErrCodeInvalidArgument), "", "0", "0", "2", "")
+}
+
+func TestLegacyAccountActivity(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+	bobLegacyID := "x509::CN=bob::CN=bob"
+
+	for i, tokenId := range []string{"1", "2", "3"} {
+		ledger.now = int64(100 * (i + 1))
+		ledger.ok(admin, "MintWithTokenURI", tokenId, "ipfs://deed/"+tokenId)
+	}
+
+	// Before bob used the canonical ID, transfers to the legacy ID are indexed under it
+	ledger.now = 400
+	ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "1")
+	ledger.now = 500
+	ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "2")
+
+	// A transfer bob signs is indexed under the canonical ID
+	ledger.now = 600
+	ledger.ok(bob, "TransferFrom", bobLegacyID, adminID, "1")
+	if got, want := activityPages(ledger, bob, bobLegacyID, 0, 0, 10), [][]int64{{400, 500}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("legacy pages %v, want %v", got, want)
+	}
+
+	// Linking the legacy ID moves its activity to the canonical ID
+	ledger.ok(bob, "SetApprovalForAll", adminID, "false")
+	ledger.now = 700
+	ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "3")
+
+	if got, want := activityPages(ledger, bob, bobID, 0, 0, 10), [][]int64{{400, 500, 600, 700}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("canonical pages %v, want %v", got, want)
+	}
+
+	// The legacy ID now reads the activity of the canonical ID
+	page := model.NewActivityPage()
+	if err := json.Unmarshal([]byte(ledger.ok(bob, "GetAccountActivity", bobLegacyID, "0", "0", "10", "")), page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Records) != 4 || page.Records[0].Account != bobID || page.Records[0].To != bobLegacyID {
+		t.Fatalf("legacy page %+v", page.Records)
+	}
+}
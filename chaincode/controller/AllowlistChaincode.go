@@ -0,0 +1,217 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"hyperledger_erc721/chaincode/model"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetMintAllowlistRoot` is invoke fnc that opens allowlist minting for the accounts of a Merkle tree.
+Quotas are counted per phase, so starting a new phase lets every account mint its quota again.
+An empty root closes allowlist minting.
+*/
+func (c *TokenERC721Contract) SetMintAllowlistRoot(ctx contractapi.TransactionContextInterface, root string, phase string) (bool, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	if root == "" {
+		metadata.MintAllowlist = nil
+	} else {
+		err = validateAllowlistHash(root)
+		if err != nil {
+			return false, err
+		}
+		if phase == "" {
+			return false, invalidArgumentError("phase must not be empty")
+		}
+
+		allowlist := model.NewMintAllowlist(root, phase)
+		if metadata.MintAllowlist != nil {
+			allowlist.Quota = metadata.MintAllowlist.Quota
+		}
+		metadata.MintAllowlist = allowlist
+	}
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`SetMintAllowlistQuota` is invoke fnc that sets how many tokens every allowlisted account may mint per phase
+*/
+func (c *TokenERC721Contract) SetMintAllowlistQuota(ctx contractapi.TransactionContextInterface, quota int) (bool, error) {
+
+	if quota < 1 {
+		return false, invalidArgumentError("quota must be a positive number")
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+	if metadata.MintAllowlist == nil {
+		return false, conflictError("allowlist minting is closed")
+	}
+
+	metadata.MintAllowlist.Quota = quota
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`GetMintAllowlist` is query fnc that returns the open allowlist, nil while allowlist minting is closed
+*/
+func (c *TokenERC721Contract) GetMintAllowlist(ctx contractapi.TransactionContextInterface) (*model.MintAllowlist, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.MintAllowlist, nil
+}
+
+/*
+`AllowlistMintsOf` is query fnc that counts the tokens an account minted from the allowlist in the current phase
+*/
+func (c *TokenERC721Contract) AllowlistMintsOf(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return 0, err
+	}
+	if metadata.MintAllowlist == nil {
+		return 0, nil
+	}
+
+	return _readAllowlistMints(ctx, metadata.MintAllowlist.Phase, account)
+}
+
+/*
+`MintAllowlisted` is invoke fnc that mints a new non-fungible token to the requesting client,
+whose canonical account must be in the allowlist tree. proof lists the hex sibling hashes from the leaf of the account up to the root.
+*/
+func (c *TokenERC721Contract) MintAllowlisted(ctx contractapi.TransactionContextInterface, tokenId string, proof []string) (*model.NFT, error) {
+
+	if tokenId == "" {
+		return nil, invalidArgumentError("tokenId must not be empty")
+	}
+
+	nft := new(model.NFT)
+	request, replayed, err := _replayRequest(ctx, nft)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return nft, nil
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return nil, err
+	}
+	allowlist := metadata.MintAllowlist
+	if allowlist == nil {
+		return nil, conflictError("allowlist minting is closed")
+	}
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proofHashes := make([][]byte, len(proof))
+	for index, hash := range proof {
+		err = validateAllowlistHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		proofHashes[index], _ = hex.DecodeString(hash)
+	}
+
+	root, _ := hex.DecodeString(allowlist.Root)
+	if !model.VerifyAllowlistProof(root, client.ID, proofHashes) {
+		return nil, unauthorizedError("account %s is not in the allowlist of phase %s", client.ID, allowlist.Phase)
+	}
+
+	minted, err := _readAllowlistMints(ctx, allowlist.Phase, client.ID)
+	if err != nil {
+		return nil, err
+	}
+	if minted >= allowlist.StoredQuota() {
+		return nil, conflictError("account %s already minted its quota of %d in phase %s", client.ID, allowlist.StoredQuota(), allowlist.Phase)
+	}
+
+	nft, err = _mint(ctx, LegacyCollectionID, tokenId, "", "", "", client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _putAllowlistMints(ctx, allowlist.Phase, client.ID, minted+1)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _completeRequest(ctx, request, nft)
+	if err != nil {
+		return nil, err
+	}
+
+	return nft, nil
+}
+
+func _readAllowlistMints(ctx contractapi.TransactionContextInterface, phase string, account string) (int, error) {
+	mintsKey, err := ctx.GetStub().CreateCompositeKey(allowlistMintPrefix, []string{phase, account})
+	if err != nil {
+		return 0, internalError("failed to CreateCompositeKey mintsKey: %v", err)
+	}
+
+	mintsBytes, err := _repository(ctx).getState(mintsKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(mintsBytes) == 0 {
+		return 0, nil
+	}
+
+	minted, err := strconv.Atoi(string(mintsBytes))
+	if err != nil {
+		return 0, internalError("failed to parse mintsBytes: %v", err)
+	}
+
+	return minted, nil
+}
+
+func _putAllowlistMints(ctx contractapi.TransactionContextInterface, phase string, account string, minted int) error {
+	mintsKey, err := ctx.GetStub().CreateCompositeKey(allowlistMintPrefix, []string{phase, account})
+	if err != nil {
+		return internalError("failed to CreateCompositeKey mintsKey: %v", err)
+	}
+
+	return _repository(ctx).putState(mintsKey, []byte(strconv.Itoa(minted)))
+}
+
+// validateAllowlistHash accepts the hex encoding of a sha256 hash, in lower or upper case
+func validateAllowlistHash(hash string) error {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != 32 {
+		return invalidArgumentError("malformed hash %s, expected 64 hex characters", hash)
+	}
+
+	return nil
+}
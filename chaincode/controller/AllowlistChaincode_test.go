@@ -0,0 +1,120 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+// allowlistTree builds the tree of three accounts and returns its hex root and the hex proof of every account
+func allowlistTree(alice, bob, carol string) (string, map[string][]string) {
+	aliceLeaf, bobLeaf, carolLeaf := model.AllowlistLeaf(alice), model.AllowlistLeaf(bob), model.AllowlistLeaf(carol)
+	aliceBob := model.AllowlistNode(aliceLeaf, bobLeaf)
+	root := model.AllowlistNode(aliceBob, carolLeaf)
+
+	proofs := map[string][]string{
+		alice: {hex.EncodeToString(bobLeaf), hex.EncodeToString(carolLeaf)},
+		bob:   {hex.EncodeToString(aliceLeaf), hex.EncodeToString(carolLeaf)},
+		carol: {hex.EncodeToString(aliceBob)},
+	}
+
+	return hex.EncodeToString(root), proofs
+}
+
+func marshalProof(t *testing.T, proof []string) string {
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(proofJSON)
+}
+
+func TestSetMintAllowlist(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	root, _ := allowlistTree("alice", "bob", "carol")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"not an admin", bob, "SetMintAllowlistRoot", []string{root, "presale"}, ErrCodeUnauthorized},
+		{"malformed root", admin, "SetMintAllowlistRoot", []string{"abcd", "presale"}, ErrCodeInvalidArgument},
+		{"empty phase", admin, "SetMintAllowlistRoot", []string{root, ""}, ErrCodeInvalidArgument},
+		{"quota while closed", admin, "SetMintAllowlistQuota", []string{"2"}, ErrCodeConflict},
+		{"quota not an admin", bob, "SetMintAllowlistQuota", []string{"2"}, ErrCodeUnauthorized},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	ledger.expect(bob, "", "GetMintAllowlist")
+
+	ledger.ok(admin, "SetMintAllowlistRoot", root, "presale")
+	ledger.fail(admin, "SetMintAllowlistQuota", string(ErrCodeInvalidArgument), "0")
+	ledger.ok(admin, "SetMintAllowlistQuota", "3")
+
+	// A new phase keeps the quota
+	ledger.ok(admin, "SetMintAllowlistRoot", root, "public")
+	ledger.expect(bob, `{"root":"`+root+`","phase":"public","quota":3}`, "GetMintAllowlist")
+
+	ledger.ok(admin, "SetMintAllowlistRoot", "", "")
+	ledger.expect(bob, "", "GetMintAllowlist")
+}
+
+func TestMintAllowlisted(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	alice := newTestClient(t, "Org2MSP", "alice")
+	bob := newTestClient(t, "Org2MSP", "bob")
+	dave := newTestClient(t, "Org3MSP", "dave")
+	aliceID, bobID := ledger.account(alice), ledger.account(bob)
+	root, proofs := allowlistTree(aliceID, bobID, ledger.account(newTestClient(t, "Org3MSP", "carol")))
+
+	ledger.fail(alice, "MintAllowlisted", string(ErrCodeConflict), "1", marshalProof(t, proofs[aliceID]))
+	ledger.ok(admin, "SetMintAllowlistRoot", root, "presale")
+	ledger.ok(admin, "MintWithTokenURI", "9", "ipfs://deed/9")
+
+	tests := []struct {
+		name    string
+		client  testClient
+		tokenId string
+		proof   []string
+		want    ErrorCode
+	}{
+		{"empty token id", alice, "", proofs[aliceID], ErrCodeInvalidArgument},
+		{"malformed proof", alice, "1", []string{"zz"}, ErrCodeInvalidArgument},
+		{"proof of another account", alice, "1", proofs[bobID], ErrCodeUnauthorized},
+		{"not in the allowlist", dave, "1", proofs[aliceID], ErrCodeUnauthorized},
+		{"already minted", alice, "9", proofs[aliceID], ErrCodeAlreadyMinted},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "MintAllowlisted", string(test.want), test.tokenId, marshalProof(t, test.proof))
+		})
+	}
+
+	ledger.ok(alice, "MintAllowlisted", "1", marshalProof(t, proofs[aliceID]))
+	ledger.expect(admin, aliceID, "OwnerOf", "1")
+	ledger.expect(admin, "1", "AllowlistMintsOf", aliceID)
+
+	// The default quota is one token per phase
+	ledger.fail(alice, "MintAllowlisted", "quota", "2", marshalProof(t, proofs[aliceID]))
+	ledger.ok(bob, "MintAllowlisted", "2", marshalProof(t, proofs[bobID]))
+
+	// Quotas restart with every phase
+	ledger.ok(admin, "SetMintAllowlistRoot", root, "public")
+	ledger.expect(admin, "0", "AllowlistMintsOf", aliceID)
+	ledger.ok(alice, "MintAllowlisted", "3", marshalProof(t, proofs[aliceID]))
+	ledger.expect(admin, "2", "BalanceOf", aliceID)
+
+	ledger.ok(admin, "Pause")
+	ledger.fail(bob, "MintAllowlisted", string(ErrCodePaused), "4", marshalProof(t, proofs[bobID]))
+}
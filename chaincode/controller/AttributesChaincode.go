@@ -0,0 +1,148 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetMetadataSchema` is invoke fnc that registers the JSON Schema token attributes are validated against at mint and on update.
+Tokens already minted are not revalidated. An empty schema removes the validation.
+*/
+func (c *TokenERC721Contract) SetMetadataSchema(ctx contractapi.TransactionContextInterface, schema string) (bool, error) {
+	return c.setMetadataSchema(ctx, LegacyCollectionID, schema)
+}
+
+/*
+`GetMetadataSchema` is query fnc that returns the JSON Schema of token attributes, empty when none is registered
+*/
+func (c *TokenERC721Contract) GetMetadataSchema(ctx contractapi.TransactionContextInterface) (string, error) {
+	return c.getMetadataSchema(ctx, LegacyCollectionID)
+}
+
+/*
+`MintWithAttributes` is invoke fnc that mints a new non-fungible token carrying on-chain attributes,
+a JSON object that must match the metadata schema
+*/
+func (c *TokenERC721Contract) MintWithAttributes(ctx contractapi.TransactionContextInterface, tokenId string, tokenURI string, attributes string) (*model.NFT, error) {
+	return c.mintWithTokenURI(ctx, LegacyCollectionID, tokenId, tokenURI, "", attributes)
+}
+
+/*
+`SetTokenAttributes` is invoke fnc that replaces the on-chain attributes of a token and emits the MetadataUpdate event
+*/
+func (c *TokenERC721Contract) SetTokenAttributes(ctx contractapi.TransactionContextInterface, tokenId string, attributes string) (*model.NFT, error) {
+	return c.setTokenAttributes(ctx, LegacyCollectionID, tokenId, attributes)
+}
+
+func (c *TokenERC721Contract) setMetadataSchema(ctx contractapi.TransactionContextInterface, collectionId string, schema string) (bool, error) {
+
+	if schema != "" {
+		_, err := model.CompileMetadataSchema(schema)
+		if err != nil {
+			return false, invalidArgumentError("malformed metadata schema: %v", err)
+		}
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.MetadataSchema = schema
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) getMetadataSchema(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	return metadata.MetadataSchema, nil
+}
+
+func (c *TokenERC721Contract) setTokenAttributes(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, attributes string) (*model.NFT, error) {
+
+	repository := _repository(ctx)
+
+	nft, err := repository.GetNFT(collectionId, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	nft.Attributes, err = _validateAttributes(ctx, collectionId, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.PutNFT(nft)
+	if err != nil {
+		return nil, err
+	}
+
+	nftBytes, err := json.Marshal(nft)
+	if err != nil {
+		return nil, internalError("failed to marshal nftBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(MetadataUpdateEventKey, nftBytes)
+	if err != nil {
+		return nil, internalError("failed to SetEvent nftBytes %s: %v", nftBytes, err)
+	}
+
+	return nft, nil
+}
+
+// _validateAttributes checks that attributes is a JSON object matching the metadata schema of the collection
+// and returns it compacted for storage. Without a schema any JSON object is accepted.
+func _validateAttributes(ctx contractapi.TransactionContextInterface, collectionId string, attributes string) (string, error) {
+	if attributes != "" {
+		var object map[string]interface{}
+		err := json.Unmarshal([]byte(attributes), &object)
+		if err != nil {
+			return "", invalidArgumentError("attributes must be a JSON object: %v", err)
+		}
+
+		compacted := new(bytes.Buffer)
+		err = json.Compact(compacted, []byte(attributes))
+		if err != nil {
+			return "", invalidArgumentError("attributes must be a JSON object: %v", err)
+		}
+		attributes = compacted.String()
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return "", err
+	}
+	if metadata.MetadataSchema == "" {
+		return attributes, nil
+	}
+
+	schema, err := model.CompileMetadataSchema(metadata.MetadataSchema)
+	if err != nil {
+		return "", internalError("failed to compile the metadata schema: %v", err)
+	}
+
+	violations, err := model.ValidateAttributes(schema, attributes)
+	if err != nil {
+		return "", invalidArgumentError("failed to validate attributes: %v", err)
+	}
+	if len(violations) > 0 {
+		return "", newContractError(ErrCodeSchemaViolation, "attributes violate the metadata schema: %s", strings.Join(violations, "; "))
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,95 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+const testMetadataSchema = `{"type":"object","properties":{"color":{"type":"string","enum":["red","blue"]},"level":{"type":"integer","minimum":1}},"required":["color"]}`
+
+func TestSetMetadataSchema(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     string
+	}{
+		{"not an admin", bob, "SetMetadataSchema", []string{testMetadataSchema}, string(ErrCodeUnauthorized)},
+		{"collection not an admin", bob, "CollectionSetMetadataSchema", []string{"deeds", testMetadataSchema}, string(ErrCodeUnauthorized)},
+		{"malformed schema", admin, "SetMetadataSchema", []string{`{"type":`}, string(ErrCodeInvalidArgument)},
+		{"unknown type", admin, "SetMetadataSchema", []string{`{"type":"color"}`}, string(ErrCodeInvalidArgument)},
+		{"remote ref", admin, "SetMetadataSchema", []string{`{"$ref":"https://example.com/attributes.json"}`}, "is not local"},
+		{"remote id", admin, "CollectionSetMetadataSchema", []string{"deeds", `{"$id":"https://example.com/attributes.json"}`}, "is not local"},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, test.want, test.args...)
+		})
+	}
+
+	ledger.expect(bob, "", "GetMetadataSchema")
+
+	// Every collection keeps its own schema
+	ledger.ok(admin, "SetMetadataSchema", testMetadataSchema)
+	ledger.expect(bob, testMetadataSchema, "GetMetadataSchema")
+	ledger.expect(bob, "", "CollectionGetMetadataSchema", "deeds")
+
+	ledger.ok(admin, "SetMetadataSchema", "")
+	ledger.expect(bob, "", "GetMetadataSchema")
+}
+
+func TestSchemaViolations(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.ok(admin, "MintWithAttributes", "1", "ipfs://deed/1", `{"color":"green"}`)
+	ledger.ok(admin, "CollectionSetMetadataSchema", "deeds", testMetadataSchema)
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		want     string
+	}{
+		{"missing property", "CollectionMintWithAttributes", []string{"deeds", "1", "ipfs://deeds/1", `{"level":2}`}, "color is required"},
+		{"no attributes", "CollectionMintWithTokenURI", []string{"deeds", "1", "ipfs://deeds/1"}, "color is required"},
+		{"enum", "CollectionMintWithAttributes", []string{"deeds", "1", "ipfs://deeds/1", `{"color":"green"}`}, "must be one of the following"},
+		{"every violation", "CollectionMintWithAttributes", []string{"deeds", "1", "ipfs://deeds/1", `{"level":0}`}, "color is required; level: Must be greater than or equal to 1"},
+		{"not an object", "CollectionMintWithAttributes", []string{"deeds", "1", "ipfs://deeds/1", `["red"]`}, string(ErrCodeInvalidArgument)},
+		{"generated ID", "CollectionMint", []string{"deeds", "ipfs://deeds/x", ledger.account(admin)}, string(ErrCodeSchemaViolation)},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(admin, test.function, test.want, test.args...)
+		})
+	}
+
+	nft := new(model.NFT)
+	if err := json.Unmarshal([]byte(ledger.ok(admin, "CollectionMintWithAttributes", "deeds", "1", "ipfs://deeds/1", `{ "color": "red" }`)), nft); err != nil {
+		t.Fatal(err)
+	}
+	if nft.Attributes != `{"color":"red"}` {
+		t.Fatalf("attributes %s, want them compacted", nft.Attributes)
+	}
+
+	ledger.fail(admin, "CollectionSetTokenAttributes", string(ErrCodeSchemaViolation), "deeds", "1", `{"color":"red","level":0}`)
+	ledger.ok(admin, "CollectionSetTokenAttributes", "deeds", "1", `{"color":"blue","level":2}`)
+	if ledger.event().EventName != MetadataUpdateEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, MetadataUpdateEventKey)
+	}
+
+	// The legacy collection has no schema until one is set, then updates of earlier tokens are validated
+	ledger.ok(admin, "SetTokenAttributes", "1", `{"color":"green","level":0}`)
+	ledger.ok(admin, "SetMetadataSchema", testMetadataSchema)
+	ledger.fail(admin, "SetTokenAttributes", string(ErrCodeSchemaViolation), "1", `{"color":"green"}`)
+	ledger.ok(admin, "SetTokenAttributes", "1", `{"color":"red"}`)
+}
@@ -0,0 +1,350 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`CreateCollection` is invoke fnc that registers a new collection of non-fungible tokens
+next to the legacy one set by `Initialize`
+*/
+func (c *TokenERC721Contract) CreateCollection(ctx contractapi.TransactionContextInterface, collectionId string, name string, symbol string, baseURI string) (bool, error) {
+	if collectionId == LegacyCollectionID {
+		return false, invalidArgumentError("collectionId must not be empty")
+	}
+
+	initialized, err := checkCollectionInitialized(ctx, collectionId)
+	if err != nil {
+		return false, err
+	}
+	if initialized {
+		return false, newContractError(ErrCodeAlreadyExists, "collection %s already exists", collectionId)
+	}
+
+	metadataKey, err := ctx.GetStub().CreateCompositeKey(collectionPrefix, []string{collectionId})
+	if err != nil {
+		return false, internalError("failed to CreateCompositeKey metadataKey: %v", err)
+	}
+
+	metadataBytes, err := json.Marshal(model.NewCollectionMetadata(collectionId, name, symbol, baseURI))
+	if err != nil {
+		return false, internalError("failed marshal collection %s: %v", collectionId, err)
+	}
+
+	err = _repository(ctx).putState(metadataKey, metadataBytes)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`CollectionName` is query fnc that returns the descriptive name of a collection
+*/
+func (c *TokenERC721Contract) CollectionName(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	return *metadata.GetName(), nil
+}
+
+/*
+`CollectionSymbol` is query fnc that returns the abbreviated name of a collection
+*/
+func (c *TokenERC721Contract) CollectionSymbol(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	return *metadata.GetSymbol(), nil
+}
+
+/*
+`CollectionTotalSupply` is query fnc that counts non-fungible tokens tracked by a collection
+*/
+func (c *TokenERC721Contract) CollectionTotalSupply(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+
+	return _repository(ctx).CountNFTs(collectionId)
+}
+
+/*
+`CollectionBalanceOf` is query fnc that counts the tokens of a collection assigned to an owner
+*/
+func (c *TokenERC721Contract) CollectionBalanceOf(ctx contractapi.TransactionContextInterface, collectionId string, owner string) (int, error) {
+
+	return _repository(ctx).CountBalance(collectionId, owner)
+}
+
+/*
+`CollectionOwnerOf` is query fnc that returns the owner of a token in a collection
+*/
+func (c *TokenERC721Contract) CollectionOwnerOf(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
+	return c.ownerOf(ctx, collectionId, tokenId)
+}
+
+/*
+`CollectionTokenURI` is query fnc that returns the URI of a token in a collection
+*/
+func (c *TokenERC721Contract) CollectionTokenURI(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
+	return c.tokenURI(ctx, collectionId, tokenId)
+}
+
+/*
+`CollectionGetApproved` is query fnc that returns the approved client for a token in a collection
+*/
+func (c *TokenERC721Contract) CollectionGetApproved(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
+	return c.getApproved(ctx, collectionId, tokenId)
+}
+
+/*
+`CollectionIsApprovedForAll` is query fnc that checks an operator approval inside a collection
+*/
+func (c *TokenERC721Contract) CollectionIsApprovedForAll(ctx contractapi.TransactionContextInterface, collectionId string, owner string, operator string) (bool, error) {
+	return c.isApprovedForAll(ctx, collectionId, owner, operator)
+}
+
+/*
+`CollectionMintWithTokenURI` is invoke fnc that mints a new token into a collection
+*/
+func (c *TokenERC721Contract) CollectionMintWithTokenURI(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string) (*model.NFT, error) {
+	return c.mintWithTokenURI(ctx, collectionId, tokenId, tokenURI, "", "")
+}
+
+/*
+`CollectionMint` is invoke fnc that mints a new token into a collection under a token ID assigned by the contract
+*/
+func (c *TokenERC721Contract) CollectionMint(ctx contractapi.TransactionContextInterface, collectionId string, tokenURI string, to string) (*model.NFT, error) {
+	return c.mint(ctx, collectionId, tokenURI, to)
+}
+
+/*
+`CollectionSetTokenIdScheme` is invoke fnc that selects how `CollectionMint` assigns token IDs of a collection
+*/
+func (c *TokenERC721Contract) CollectionSetTokenIdScheme(ctx contractapi.TransactionContextInterface, collectionId string, scheme string) (bool, error) {
+	return c.setTokenIdScheme(ctx, collectionId, scheme)
+}
+
+/*
+`CollectionTokenIdScheme` is query fnc that returns how `CollectionMint` assigns token IDs of a collection
+*/
+func (c *TokenERC721Contract) CollectionTokenIdScheme(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+	return c.tokenIdScheme(ctx, collectionId)
+}
+
+/*
+`CollectionMintWithAttributes` is invoke fnc that mints a new token into a collection carrying on-chain attributes
+*/
+func (c *TokenERC721Contract) CollectionMintWithAttributes(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string, attributes string) (*model.NFT, error) {
+	return c.mintWithTokenURI(ctx, collectionId, tokenId, tokenURI, "", attributes)
+}
+
+/*
+`CollectionSetTokenAttributes` is invoke fnc that replaces the on-chain attributes of a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionSetTokenAttributes(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, attributes string) (*model.NFT, error) {
+	return c.setTokenAttributes(ctx, collectionId, tokenId, attributes)
+}
+
+/*
+`CollectionSetMetadataSchema` is invoke fnc that registers the JSON Schema token attributes of a collection are validated against
+*/
+func (c *TokenERC721Contract) CollectionSetMetadataSchema(ctx contractapi.TransactionContextInterface, collectionId string, schema string) (bool, error) {
+	return c.setMetadataSchema(ctx, collectionId, schema)
+}
+
+/*
+`CollectionGetMetadataSchema` is query fnc that returns the JSON Schema of token attributes of a collection
+*/
+func (c *TokenERC721Contract) CollectionGetMetadataSchema(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+	return c.getMetadataSchema(ctx, collectionId)
+}
+
+/*
+`CollectionMintWithContentHash` is invoke fnc that mints a new token into a collection anchored to the sha256 of its metadata document
+*/
+func (c *TokenERC721Contract) CollectionMintWithContentHash(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string, contentHash string) (*model.NFT, error) {
+	return c.mintWithContentHash(ctx, collectionId, tokenId, tokenURI, contentHash)
+}
+
+/*
+`CollectionVerifyContent` is query fnc that reports whether hash matches the content hash anchored to a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionVerifyContent(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, hash string) (bool, error) {
+	return c.verifyContent(ctx, collectionId, tokenId, hash)
+}
+
+/*
+`CollectionTransferFrom` is invoke fnc that moves a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionTransferFrom(ctx contractapi.TransactionContextInterface, collectionId string, from string, to string, tokenId string) (bool, error) {
+	return c.transferFrom(ctx, collectionId, from, to, tokenId)
+}
+
+/*
+`CollectionApprove` is invoke fnc that changes the approved client for a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionApprove(ctx contractapi.TransactionContextInterface, collectionId string, operator string, tokenId string) (bool, error) {
+	return c.approve(ctx, collectionId, operator, tokenId)
+}
+
+/*
+`CollectionSetApprovalForAll` is invoke fnc that enables or disables an operator for the sender's tokens of a collection
+*/
+func (c *TokenERC721Contract) CollectionSetApprovalForAll(ctx contractapi.TransactionContextInterface, collectionId string, operator string, approved bool) (bool, error) {
+	return c.setApprovalForAll(ctx, collectionId, operator, approved)
+}
+
+/*
+`CollectionSetOperatorApproval` is invoke fnc that grants an operator a bounded approval over the message sender's tokens of a collection
+*/
+func (c *TokenERC721Contract) CollectionSetOperatorApproval(ctx contractapi.TransactionContextInterface, collectionId string, operator string, expiresAt int64, tokenIds []string, maxTransfers int) (bool, error) {
+	return c.setOperatorApproval(ctx, collectionId, operator, expiresAt, tokenIds, maxTransfers)
+}
+
+/*
+`CollectionGetOperatorApproval` is query fnc that returns the full grant of owner to operator in a collection
+*/
+func (c *TokenERC721Contract) CollectionGetOperatorApproval(ctx contractapi.TransactionContextInterface, collectionId string, owner string, operator string) (*model.Approval, error) {
+	return c.getOperatorApproval(ctx, collectionId, owner, operator)
+}
+
+/*
+`CollectionGetOperatorsOf` is query fnc that lists the operator grants of owner in a collection that are in force
+*/
+func (c *TokenERC721Contract) CollectionGetOperatorsOf(ctx contractapi.TransactionContextInterface, collectionId string, owner string) ([]*model.Approval, error) {
+	return c.getOperatorsOf(ctx, collectionId, owner)
+}
+
+/*
+`CollectionGetApprovedTokensOf` is query fnc that lists the tokens of owner in a collection that have an approved client
+*/
+func (c *TokenERC721Contract) CollectionGetApprovedTokensOf(ctx contractapi.TransactionContextInterface, collectionId string, owner string) ([]*model.NFT, error) {
+	return c.getApprovedTokensOf(ctx, collectionId, owner)
+}
+
+/*
+`CollectionBurn` is invoke fnc that burns a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionBurn(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (bool, error) {
+	return c.burn(ctx, collectionId, tokenId)
+}
+
+/*
+`CollectionFreezeToken` is invoke fnc that puts a token of a collection under compliance hold
+*/
+func (c *TokenERC721Contract) CollectionFreezeToken(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, reason string) (bool, error) {
+	return c.setTokenFrozen(ctx, collectionId, tokenId, model.FreezeAction, reason)
+}
+
+/*
+`CollectionUnfreezeToken` is invoke fnc that releases the compliance hold of a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionUnfreezeToken(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, reason string) (bool, error) {
+	return c.setTokenFrozen(ctx, collectionId, tokenId, model.UnfreezeAction, reason)
+}
+
+/*
+`CollectionIsTokenFrozen` is query fnc that reports whether a token of a collection is under compliance hold
+*/
+func (c *TokenERC721Contract) CollectionIsTokenFrozen(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (bool, error) {
+	return c.isTokenFrozen(ctx, collectionId, tokenId)
+}
+
+/*
+`CollectionSetTokenEndorsementPolicy` is invoke fnc that sets the key-level endorsement policy of a token of a collection,
+see `SetTokenEndorsementPolicy`
+*/
+func (c *TokenERC721Contract) CollectionSetTokenEndorsementPolicy(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, orgs []string) (bool, error) {
+	return c.setTokenEndorsementPolicy(ctx, collectionId, tokenId, orgs)
+}
+
+/*
+`CollectionGetTokenEndorsementPolicy` is query fnc that lists the orgs that must endorse writes of a token of a collection
+*/
+func (c *TokenERC721Contract) CollectionGetTokenEndorsementPolicy(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) ([]string, error) {
+	return c.tokenEndorsementPolicy(ctx, collectionId, tokenId)
+}
+
+/*
+`CollectionApproveRecovery` is invoke fnc that approves reassigning a token of a collection under a legal case
+*/
+func (c *TokenERC721Contract) CollectionApproveRecovery(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string, to string) (*model.RecoveryApproval, error) {
+	return c.approveRecovery(ctx, collectionId, caseReference, tokenId, to)
+}
+
+/*
+`CollectionForceTransfer` is invoke fnc that reassigns a token of a collection for a legal case
+*/
+func (c *TokenERC721Contract) CollectionForceTransfer(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, to string, caseReference string) (*model.RecoveryRecord, error) {
+	return c.forceTransfer(ctx, collectionId, tokenId, to, caseReference)
+}
+
+/*
+`CollectionGetRecoveryRecord` is query fnc that returns the recovery record of a token of a collection under a legal case
+*/
+func (c *TokenERC721Contract) CollectionGetRecoveryRecord(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string) (*model.RecoveryRecord, error) {
+	return c.getRecoveryRecord(ctx, collectionId, caseReference, tokenId)
+}
+
+func _readCollectionMetadata(ctx contractapi.TransactionContextInterface, collectionId string) (*model.ERC721Metadata, error) {
+	var err error
+	metadataKey := InitialKey
+
+	if collectionId != LegacyCollectionID {
+		metadataKey, err = ctx.GetStub().CreateCompositeKey(collectionPrefix, []string{collectionId})
+		if err != nil {
+			return nil, internalError("failed to CreateCompositeKey metadataKey: %v", err)
+		}
+	}
+
+	metadataBytes, err := _repository(ctx).getState(metadataKey)
+	if err != nil {
+		return nil, err
+	}
+	if metadataBytes == nil {
+		return nil, uninitializedError(collectionId)
+	}
+
+	metadata := model.NewERC721Metadata("", "")
+	err = json.Unmarshal(metadataBytes, metadata)
+	if err != nil {
+		return nil, internalError("failed unmarshal")
+	}
+
+	return metadata, nil
+}
+
+// _putCollectionMetadata stores the metadata of the legacy collection under InitialKey
+// and the metadata of any other collection under its collection key
+func _putCollectionMetadata(ctx contractapi.TransactionContextInterface, metadata *model.ERC721Metadata) error {
+	var err error
+	metadataKey := InitialKey
+
+	if metadata.CollectionId != LegacyCollectionID {
+		metadataKey, err = ctx.GetStub().CreateCompositeKey(collectionPrefix, []string{metadata.CollectionId})
+		if err != nil {
+			return internalError("failed to CreateCompositeKey metadataKey: %v", err)
+		}
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return internalError("failed to marshal metadata: %v", err)
+	}
+
+	err = _repository(ctx).putState(metadataKey, metadataBytes)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package chaincode
+
+import "testing"
+
+func TestCreateCollection(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	tests := []struct {
+		name         string
+		client       testClient
+		collectionId string
+		want         ErrorCode
+	}{
+		{"not an admin", bob, "deeds", ErrCodeUnauthorized},
+		{"empty id", admin, "", ErrCodeInvalidArgument},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "CreateCollection", string(test.want), test.collectionId, "Deeds", "DEED", "ipfs://deeds/")
+		})
+	}
+
+	ledger.fail(admin, "CollectionMintWithTokenURI", string(ErrCodeCollectionNotFound), "deeds", "1", "")
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.fail(admin, "CreateCollection", string(ErrCodeAlreadyExists), "deeds", "Deeds", "DEED", "ipfs://deeds/")
+
+	ledger.expect(admin, "Deeds", "CollectionName", "deeds")
+	ledger.expect(admin, "DEED", "CollectionSymbol", "deeds")
+	ledger.expect(admin, "HLF", "CollectionSymbol", LegacyCollectionID)
+}
+
+func TestCollectionIsolation(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://legacy/1")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "1", "")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "2", "ipfs://own/2")
+	ledger.ok(admin, "CollectionTransferFrom", "deeds", adminID, bobID, "1")
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		want     string
+	}{
+		{"base URI", "CollectionTokenURI", []string{"deeds", "1"}, "ipfs://deeds/1"},
+		{"own URI", "CollectionTokenURI", []string{"deeds", "2"}, "ipfs://own/2"},
+		{"legacy URI", "TokenURI", []string{"1"}, "ipfs://legacy/1"},
+		{"legacy supply", "TotalSupply", nil, "1"},
+		{"collection supply", "CollectionTotalSupply", []string{"deeds"}, "2"},
+		{"legacy collection supply", "CollectionTotalSupply", []string{LegacyCollectionID}, "1"},
+		{"collection owner", "CollectionOwnerOf", []string{"deeds", "1"}, bobID},
+		{"legacy owner", "OwnerOf", []string{"1"}, adminID},
+		{"collection balance", "CollectionBalanceOf", []string{"deeds", bobID}, "1"},
+		{"legacy balance", "BalanceOf", []string{bobID}, "0"},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.expect(admin, test.want, test.function, test.args...)
+		})
+	}
+
+	// Operator approvals are scoped to the collection they were granted in
+	ledger.ok(bob, "CollectionSetApprovalForAll", "deeds", adminID, "true")
+	ledger.expect(admin, "false", "IsApprovedForAll", bobID, adminID)
+	ledger.expect(admin, "true", "CollectionIsApprovedForAll", "deeds", bobID, adminID)
+	ledger.ok(admin, "CollectionTransferFrom", "deeds", bobID, adminID, "1")
+	ledger.ok(admin, "CollectionBurn", "deeds", "1")
+	ledger.expect(admin, adminID, "OwnerOf", "1")
+}
@@ -0,0 +1,264 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`FreezeAccount` is invoke fnc that puts an account under compliance hold in every collection.
+A frozen account cannot send, receive, approve or burn tokens, and operators cannot move the tokens it owns.
+*/
+func (c *TokenERC721Contract) FreezeAccount(ctx contractapi.TransactionContextInterface, account string, reason string) (bool, error) {
+	return c.setAccountFrozen(ctx, account, model.FreezeAction, reason)
+}
+
+/*
+`UnfreezeAccount` is invoke fnc that releases the compliance hold of an account
+*/
+func (c *TokenERC721Contract) UnfreezeAccount(ctx contractapi.TransactionContextInterface, account string, reason string) (bool, error) {
+	return c.setAccountFrozen(ctx, account, model.UnfreezeAction, reason)
+}
+
+/*
+`IsFrozen` is query fnc that reports whether an account is under compliance hold.
+A legacy ID is frozen with the canonical account it is an alias of.
+*/
+func (c *TokenERC721Contract) IsFrozen(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+
+	aliases, err := _accountAliases(ctx, account)
+	if err != nil {
+		return false, err
+	}
+
+	for _, alias := range aliases {
+		holdKey, err := _frozenAccountKey(ctx, alias)
+		if err != nil {
+			return false, err
+		}
+
+		frozen, err := _isFrozen(ctx, holdKey)
+		if err != nil {
+			return false, err
+		}
+		if frozen {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+/*
+`FreezeToken` is invoke fnc that puts a single token under compliance hold, it cannot be transferred, approved, burned or fractionalized
+*/
+func (c *TokenERC721Contract) FreezeToken(ctx contractapi.TransactionContextInterface, tokenId string, reason string) (bool, error) {
+	return c.setTokenFrozen(ctx, LegacyCollectionID, tokenId, model.FreezeAction, reason)
+}
+
+/*
+`UnfreezeToken` is invoke fnc that releases the compliance hold of a token
+*/
+func (c *TokenERC721Contract) UnfreezeToken(ctx contractapi.TransactionContextInterface, tokenId string, reason string) (bool, error) {
+	return c.setTokenFrozen(ctx, LegacyCollectionID, tokenId, model.UnfreezeAction, reason)
+}
+
+/*
+`IsTokenFrozen` is query fnc that reports whether a token is under compliance hold
+*/
+func (c *TokenERC721Contract) IsTokenFrozen(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+	return c.isTokenFrozen(ctx, LegacyCollectionID, tokenId)
+}
+
+func (c *TokenERC721Contract) setAccountFrozen(ctx contractapi.TransactionContextInterface, account string, action string, reason string) (bool, error) {
+
+	if account == "" {
+		return false, invalidArgumentError("account must not be empty")
+	}
+
+	holdKey, err := _frozenAccountKey(ctx, account)
+	if err != nil {
+		return false, err
+	}
+
+	hold, err := _newComplianceHold(ctx, action, reason)
+	if err != nil {
+		return false, err
+	}
+	hold.Account = account
+
+	err = _putComplianceHold(ctx, holdKey, hold)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) setTokenFrozen(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, action string, reason string) (bool, error) {
+
+	exists, err := _repository(ctx).NFTExists(collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, tokenNotFoundError(tokenId)
+	}
+
+	holdKey, err := _frozenTokenKey(ctx, collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	hold, err := _newComplianceHold(ctx, action, reason)
+	if err != nil {
+		return false, err
+	}
+	hold.CollectionId = collectionId
+	hold.TokenId = tokenId
+
+	err = _putComplianceHold(ctx, holdKey, hold)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) isTokenFrozen(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (bool, error) {
+
+	holdKey, err := _frozenTokenKey(ctx, collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	return _isFrozen(ctx, holdKey)
+}
+
+// _checkNotFrozen rejects a transaction that touches a frozen token or a frozen account.
+// Accounts are checked together with their canonical alias, empty values are skipped.
+func _checkNotFrozen(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, accounts ...string) error {
+	if tokenId != "" {
+		holdKey, err := _frozenTokenKey(ctx, collectionId, tokenId)
+		if err != nil {
+			return err
+		}
+
+		frozen, err := _isFrozen(ctx, holdKey)
+		if err != nil {
+			return err
+		}
+		if frozen {
+			return newContractError(ErrCodeFrozen, "the non-fungible token %s is frozen", tokenId)
+		}
+	}
+
+	for _, account := range accounts {
+		if account == "" || account == ZeroAddress {
+			continue
+		}
+
+		aliases, err := _accountAliases(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		for _, alias := range aliases {
+			holdKey, err := _frozenAccountKey(ctx, alias)
+			if err != nil {
+				return err
+			}
+
+			frozen, err := _isFrozen(ctx, holdKey)
+			if err != nil {
+				return err
+			}
+			if frozen {
+				return newContractError(ErrCodeFrozen, "the account %s is frozen", account)
+			}
+		}
+	}
+
+	return nil
+}
+
+// _newComplianceHold records the calling admin and the transaction time of a freeze or unfreeze
+func _newComplianceHold(ctx contractapi.TransactionContextInterface, action string, reason string) (*model.ComplianceHold, error) {
+	if reason == "" {
+		return nil, invalidArgumentError("reason must not be empty")
+	}
+
+	admin, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewComplianceHold(action, reason, admin.ID, now), nil
+}
+
+// _putComplianceHold stores a freeze or deletes the hold released by an unfreeze, then emits the Compliance event
+func _putComplianceHold(ctx contractapi.TransactionContextInterface, holdKey string, hold *model.ComplianceHold) error {
+	frozen, err := _isFrozen(ctx, holdKey)
+	if err != nil {
+		return err
+	}
+
+	holdBytes, err := json.Marshal(hold)
+	if err != nil {
+		return internalError("failed to marshal holdBytes: %v", err)
+	}
+
+	subject := "the account " + hold.Account
+	if hold.TokenId != "" {
+		subject = "the non-fungible token " + hold.TokenId
+	}
+
+	repository := _repository(ctx)
+
+	if hold.Action == model.FreezeAction {
+		if frozen {
+			return conflictError("%s is already frozen", subject)
+		}
+		err = repository.putState(holdKey, holdBytes)
+	} else {
+		if !frozen {
+			return conflictError("%s is not frozen", subject)
+		}
+		err = repository.delState(holdKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().SetEvent(ComplianceEventKey, holdBytes)
+	if err != nil {
+		return internalError("failed to SetEvent holdBytes %s: %v", holdBytes, err)
+	}
+
+	return nil
+}
+
+func _isFrozen(ctx contractapi.TransactionContextInterface, holdKey string) (bool, error) {
+	holdBytes, err := _repository(ctx).getState(holdKey)
+	if err != nil {
+		return false, err
+	}
+
+	return len(holdBytes) > 0, nil
+}
+
+// Account holds apply to every collection, so their key carries no collection attribute
+func _frozenAccountKey(ctx contractapi.TransactionContextInterface, account string) (string, error) {
+	return _repository(ctx).key(frozenAccountPrefix, LegacyCollectionID, account)
+}
+
+func _frozenTokenKey(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
+	return _repository(ctx).key(frozenTokenPrefix, collectionId, tokenId)
+}
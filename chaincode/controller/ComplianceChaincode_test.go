@@ -0,0 +1,115 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"not an admin", bob, "FreezeAccount", []string{adminID, "court order"}, ErrCodeUnauthorized},
+		{"empty account", admin, "FreezeAccount", []string{"", "court order"}, ErrCodeInvalidArgument},
+		{"empty reason", admin, "FreezeAccount", []string{bobID, ""}, ErrCodeInvalidArgument},
+		{"unfreeze not frozen", admin, "UnfreezeAccount", []string{bobID, "released"}, ErrCodeConflict},
+		{"unknown token", admin, "FreezeToken", []string{"2", "court order"}, ErrCodeTokenNotFound},
+		{"token not an admin", bob, "FreezeToken", []string{"1", "court order"}, ErrCodeUnauthorized},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	ledger.now = 1000
+	ledger.ok(admin, "FreezeAccount", bobID, "court order 1")
+
+	hold := new(model.ComplianceHold)
+	if err := json.Unmarshal(ledger.event().Payload, hold); err != nil {
+		t.Fatal(err)
+	}
+	want := model.ComplianceHold{Action: model.FreezeAction, Account: bobID, Reason: "court order 1", Admin: adminID, Timestamp: 1000}
+	if ledger.event().EventName != ComplianceEventKey || *hold != want {
+		t.Fatalf("event %s %+v, want %+v", ledger.event().EventName, hold, want)
+	}
+
+	ledger.expect(bob, "true", "IsFrozen", bobID)
+	ledger.fail(admin, "FreezeAccount", string(ErrCodeConflict), bobID, "court order 2")
+
+	// The legacy ID of bob is frozen with the canonical account once bob migrated
+	bobLegacyID := "x509::CN=bob::CN=bob"
+	ledger.expect(bob, "false", "IsFrozen", bobLegacyID)
+	ledger.ok(bob, "SetApprovalForAll", adminID, "false")
+	ledger.expect(bob, "true", "IsFrozen", bobLegacyID)
+
+	ledger.ok(admin, "UnfreezeAccount", bobID, "released")
+	ledger.expect(bob, "false", "IsFrozen", bobID)
+	ledger.expect(bob, "false", "IsFrozen", bobLegacyID)
+}
+
+func TestFrozenTransactions(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	carol := newTestClient(t, "Org3MSP", "carol")
+	adminID, bobID, carolID := ledger.account(admin), ledger.account(bob), ledger.account(carol)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	for _, tokenId := range []string{"1", "2"} {
+		ledger.ok(admin, "MintWithTokenURI", tokenId, "ipfs://deed/"+tokenId)
+	}
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "1", "")
+	ledger.ok(admin, "SetApprovalForAll", carolID, "true")
+
+	tests := []struct {
+		name     string
+		hold     string
+		release  string
+		target   []string
+		client   testClient
+		function string
+		args     []string
+	}{
+		{"transfer frozen token", "FreezeToken", "UnfreezeToken", []string{"1"}, admin, "TransferFrom", []string{adminID, bobID, "1"}},
+		{"transfer by frozen owner", "FreezeAccount", "UnfreezeAccount", []string{adminID}, admin, "TransferFrom", []string{adminID, bobID, "1"}},
+		{"transfer to frozen recipient", "FreezeAccount", "UnfreezeAccount", []string{bobID}, admin, "TransferFrom", []string{adminID, bobID, "1"}},
+		{"operator moves frozen owner's token", "FreezeAccount", "UnfreezeAccount", []string{adminID}, carol, "TransferFrom", []string{adminID, bobID, "1"}},
+		{"frozen operator", "FreezeAccount", "UnfreezeAccount", []string{carolID}, carol, "TransferFrom", []string{adminID, bobID, "1"}},
+		{"approve frozen token", "FreezeToken", "UnfreezeToken", []string{"1"}, admin, "Approve", []string{bobID, "1"}},
+		{"approve for all by frozen owner", "FreezeAccount", "UnfreezeAccount", []string{adminID}, admin, "SetApprovalForAll", []string{bobID, "true"}},
+		{"burn frozen token", "FreezeToken", "UnfreezeToken", []string{"2"}, admin, "Burn", []string{"2"}},
+		{"frozen collection token", "CollectionFreezeToken", "CollectionUnfreezeToken", []string{"deeds", "1"}, admin, "CollectionTransferFrom", []string{"deeds", adminID, bobID, "1"}},
+		{"frozen owner in every collection", "FreezeAccount", "UnfreezeAccount", []string{adminID}, admin, "CollectionTransferFrom", []string{"deeds", adminID, bobID, "1"}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.ok(admin, test.hold, append(test.target, "court order")...)
+			ledger.fail(test.client, test.function, string(ErrCodeFrozen), test.args...)
+			ledger.ok(admin, test.release, append(test.target, "released")...)
+		})
+	}
+
+	// A frozen token of one collection leaves the same ID of another collection alone
+	ledger.ok(admin, "CollectionFreezeToken", "deeds", "1", "court order")
+	ledger.expect(bob, "true", "CollectionIsTokenFrozen", "deeds", "1")
+	ledger.expect(bob, "false", "IsTokenFrozen", "1")
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "1")
+
+	// A frozen account can still revoke its operators
+	ledger.ok(admin, "FreezeAccount", adminID, "court order")
+	ledger.ok(admin, "SetApprovalForAll", carolID, "false")
+	ledger.expect(bob, "false", "IsApprovedForAll", adminID, carolID)
+}
@@ -0,0 +1,54 @@
+package chaincode
+
+import (
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`MintWithContentHash` is invoke fnc that mints a new non-fungible token anchored to the sha256 of the metadata document at tokenURI.
+contentHash is either `sha256:<hex digest>`, a bare hex digest or a hex sha2-256 multihash.
+*/
+func (c *TokenERC721Contract) MintWithContentHash(ctx contractapi.TransactionContextInterface, tokenId string, tokenURI string, contentHash string) (*model.NFT, error) {
+	return c.mintWithContentHash(ctx, LegacyCollectionID, tokenId, tokenURI, contentHash)
+}
+
+/*
+`VerifyContent` is query fnc that reports whether hash matches the content hash anchored at mint
+*/
+func (c *TokenERC721Contract) VerifyContent(ctx contractapi.TransactionContextInterface, tokenId string, hash string) (bool, error) {
+	return c.verifyContent(ctx, LegacyCollectionID, tokenId, hash)
+}
+
+func (c *TokenERC721Contract) mintWithContentHash(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string, contentHash string) (*model.NFT, error) {
+
+	if tokenURI == "" {
+		return nil, invalidArgumentError("tokenURI must not be empty")
+	}
+
+	normalizedHash, err := model.NormalizeContentHash(contentHash)
+	if err != nil {
+		return nil, invalidArgumentError("malformed contentHash %s: %v", contentHash, err)
+	}
+
+	return c.mintWithTokenURI(ctx, collectionId, tokenId, tokenURI, normalizedHash, "")
+}
+
+func (c *TokenERC721Contract) verifyContent(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, hash string) (bool, error) {
+
+	normalizedHash, err := model.NormalizeContentHash(hash)
+	if err != nil {
+		return false, invalidArgumentError("malformed hash %s: %v", hash, err)
+	}
+
+	nft, err := _repository(ctx).GetNFT(collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+	if nft.ContentHash == "" {
+		return false, conflictError("the token %s has no anchored content hash", tokenId)
+	}
+
+	return nft.ContentHash == normalizedHash, nil
+}
@@ -0,0 +1,78 @@
+package chaincode
+
+import "testing"
+
+const (
+	contentDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	otherDigest   = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+)
+
+func TestMintWithContentHash(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"not an admin", bob, "MintWithContentHash", []string{"1", "ipfs://deed/1", contentDigest}, ErrCodeUnauthorized},
+		{"empty token URI", admin, "MintWithContentHash", []string{"1", "", contentDigest}, ErrCodeInvalidArgument},
+		{"malformed hash", admin, "MintWithContentHash", []string{"1", "ipfs://deed/1", "md5:abc"}, ErrCodeInvalidArgument},
+		{"unknown collection", admin, "CollectionMintWithContentHash", []string{"lands", "1", "ipfs://lands/1", contentDigest}, ErrCodeCollectionNotFound},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	ledger.ok(admin, "MintWithContentHash", "1", "ipfs://deed/1", "sha256:"+contentDigest)
+	ledger.ok(admin, "CollectionMintWithContentHash", "deeds", "1", "ipfs://deeds/1", contentDigest)
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+	ledger.fail(admin, "MintWithContentHash", string(ErrCodeAlreadyMinted), "1", "ipfs://deed/1", contentDigest)
+}
+
+func TestVerifyContent(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.ok(admin, "MintWithContentHash", "1", "ipfs://deed/1", "sha256:"+contentDigest)
+	ledger.ok(admin, "CollectionMintWithContentHash", "deeds", "1", "ipfs://deeds/1", otherDigest)
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		want     string
+	}{
+		{"prefixed digest", "VerifyContent", []string{"1", "sha256:" + contentDigest}, "true"},
+		{"bare digest", "VerifyContent", []string{"1", contentDigest}, "true"},
+		{"multihash", "VerifyContent", []string{"1", "1220" + contentDigest}, "true"},
+		{"other content", "VerifyContent", []string{"1", otherDigest}, "false"},
+		{"collection token", "CollectionVerifyContent", []string{"deeds", "1", otherDigest}, "true"},
+		{"collection other content", "CollectionVerifyContent", []string{"deeds", "1", contentDigest}, "false"},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.expect(bob, test.want, test.function, test.args...)
+		})
+	}
+
+	ledger.fail(bob, "VerifyContent", string(ErrCodeConflict), "2", contentDigest)
+	ledger.fail(bob, "VerifyContent", string(ErrCodeTokenNotFound), "3", contentDigest)
+	ledger.fail(bob, "VerifyContent", string(ErrCodeInvalidArgument), "1", "abc")
+
+	// The anchored hash survives a binary encoding of the record
+	ledger.ok(admin, "SetStateEncoding", "binary")
+	ledger.ok(admin, "TransferFrom", ledger.account(admin), ledger.account(bob), "1")
+	ledger.expect(bob, "true", "VerifyContent", "1", contentDigest)
+}
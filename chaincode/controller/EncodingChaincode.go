@@ -0,0 +1,45 @@
+package chaincode
+
+import (
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetStateEncoding` is invoke fnc that selects the encoding nft and approval records are written with, json or binary.
+Records already stored keep their encoding until their next write and stay readable either way.
+*/
+func (c *TokenERC721Contract) SetStateEncoding(ctx contractapi.TransactionContextInterface, encoding string) (bool, error) {
+
+	if !model.IsValidEncoding(encoding) {
+		return false, invalidArgumentError("unknown encoding %s", encoding)
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.StateEncoding = encoding
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`StateEncoding` is query fnc that returns the encoding nft and approval records are written with
+*/
+func (c *TokenERC721Contract) StateEncoding(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return "", err
+	}
+
+	return metadata.StoredStateEncoding(), nil
+}
@@ -0,0 +1,59 @@
+package chaincode
+
+import (
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+func TestSetStateEncoding(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	tests := []struct {
+		name     string
+		client   testClient
+		encoding string
+		want     ErrorCode
+	}{
+		{"unknown encoding", admin, "protobuf", ErrCodeInvalidArgument},
+		{"empty encoding", admin, "", ErrCodeInvalidArgument},
+		{"not an admin", bob, model.BinaryEncoding, ErrCodeUnauthorized},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "SetStateEncoding", string(test.want), test.encoding)
+		})
+	}
+
+	ledger.expect(bob, model.JSONEncoding, "StateEncoding")
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "SetApprovalForAll", bobID, "true")
+
+	// Records written before the switch stay readable and are rewritten in the new encoding
+	ledger.ok(admin, "SetStateEncoding", model.BinaryEncoding)
+	ledger.expect(bob, model.BinaryEncoding, "StateEncoding")
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+
+	nftKey, err := ledger.stub.CreateCompositeKey(nftPrefix, []string{"1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.IsBinaryEncoded(ledger.stub.State[nftKey]) {
+		t.Fatal("record of token 1 was rewritten without a write")
+	}
+
+	ledger.expect(bob, "true", "IsApprovedForAll", adminID, bobID)
+	ledger.ok(bob, "TransferFrom", adminID, bobID, "1")
+	if !model.IsBinaryEncoded(ledger.stub.State[nftKey]) {
+		t.Fatal("record of token 1 is not binary after its transfer")
+	}
+
+	// Switching back keeps the binary records readable
+	ledger.ok(admin, "SetStateEncoding", model.JSONEncoding)
+	ledger.expect(bob, bobID, "OwnerOf", "1")
+	ledger.expect(bob, "ipfs://deed/2", "TokenURI", "2")
+	ledger.ok(bob, "TransferFrom", adminID, bobID, "2")
+	ledger.expect(bob, "2", "BalanceOf", bobID)
+}
@@ -0,0 +1,113 @@
+package chaincode
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetTokenEndorsementPolicy` is invoke fnc that sets a key-level endorsement policy on the record of a token.
+Every later transaction writing that record, such as a transfer, approval or burn, must then be endorsed
+by a peer of every org in orgs in addition to the chaincode-wide endorsement policy,
+which still governs the balance and other records the transaction writes.
+Burning the token deletes the record together with its policy.
+An empty orgs list removes the key-level policy. Replacing an existing policy must itself satisfy that policy.
+*/
+func (c *TokenERC721Contract) SetTokenEndorsementPolicy(ctx contractapi.TransactionContextInterface, tokenId string, orgs []string) (bool, error) {
+	return c.setTokenEndorsementPolicy(ctx, LegacyCollectionID, tokenId, orgs)
+}
+
+/*
+`GetTokenEndorsementPolicy` is query fnc that lists the orgs that must endorse writes of the record of a token,
+an empty list means only the chaincode-wide endorsement policy applies
+*/
+func (c *TokenERC721Contract) GetTokenEndorsementPolicy(ctx contractapi.TransactionContextInterface, tokenId string) ([]string, error) {
+	return c.tokenEndorsementPolicy(ctx, LegacyCollectionID, tokenId)
+}
+
+func (c *TokenERC721Contract) setTokenEndorsementPolicy(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, orgs []string) (bool, error) {
+
+	repository := _repository(ctx)
+
+	exists, err := repository.NFTExists(collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, tokenNotFoundError(tokenId)
+	}
+
+	nftKey, err := repository.key(nftPrefix, collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	var policy []byte
+	if len(orgs) > 0 {
+		for _, org := range orgs {
+			if org == "" {
+				return false, invalidArgumentError("org must not be empty")
+			}
+		}
+
+		endorsementPolicy, err := statebased.NewStateEP(nil)
+		if err != nil {
+			return false, internalError("failed to create endorsement policy: %v", err)
+		}
+
+		err = endorsementPolicy.AddOrgs(statebased.RoleTypeMember, orgs...)
+		if err != nil {
+			return false, invalidArgumentError("failed to add orgs to endorsement policy: %v", err)
+		}
+
+		policy, err = endorsementPolicy.Policy()
+		if err != nil {
+			return false, internalError("failed to marshal endorsement policy: %v", err)
+		}
+	}
+
+	err = ctx.GetStub().SetStateValidationParameter(nftKey, policy)
+	if err != nil {
+		return false, internalError("failed to SetStateValidationParameter %s: %v", tokenId, err)
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) tokenEndorsementPolicy(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) ([]string, error) {
+
+	repository := _repository(ctx)
+
+	exists, err := repository.NFTExists(collectionId, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, tokenNotFoundError(tokenId)
+	}
+
+	nftKey, err := repository.key(nftPrefix, collectionId, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ctx.GetStub().GetStateValidationParameter(nftKey)
+	if err != nil {
+		return nil, internalError("failed to GetStateValidationParameter %s: %v", tokenId, err)
+	}
+	if len(policy) == 0 {
+		return []string{}, nil
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, internalError("failed to parse endorsement policy of %s: %v", tokenId, err)
+	}
+
+	orgs := endorsementPolicy.ListOrgs()
+	sort.Strings(orgs)
+
+	return orgs, nil
+}
@@ -0,0 +1,49 @@
+package chaincode
+
+import "testing"
+
+func TestSetTokenEndorsementPolicy(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "7", "")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"not an admin", bob, "SetTokenEndorsementPolicy", []string{"1", `["Org2MSP"]`}, ErrCodeUnauthorized},
+		{"unknown token", admin, "SetTokenEndorsementPolicy", []string{"2", `["Org2MSP"]`}, ErrCodeTokenNotFound},
+		{"empty org", admin, "SetTokenEndorsementPolicy", []string{"1", `["Org2MSP",""]`}, ErrCodeInvalidArgument},
+		{"token of another collection", admin, "SetTokenEndorsementPolicy", []string{"7", `["Org2MSP"]`}, ErrCodeTokenNotFound},
+		{"unknown collection", admin, "CollectionSetTokenEndorsementPolicy", []string{"lands", "7", `["Org2MSP"]`}, ErrCodeCollectionNotFound},
+		{"collection not an admin", bob, "CollectionSetTokenEndorsementPolicy", []string{"deeds", "7", `["Org2MSP"]`}, ErrCodeUnauthorized},
+		{"collection unknown token", admin, "CollectionGetTokenEndorsementPolicy", []string{"deeds", "1"}, ErrCodeTokenNotFound},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	ledger.expect(bob, "[]", "GetTokenEndorsementPolicy", "1")
+
+	ledger.ok(admin, "SetTokenEndorsementPolicy", "1", `["Org2MSP","Org1MSP"]`)
+	ledger.expect(bob, `["Org1MSP","Org2MSP"]`, "GetTokenEndorsementPolicy", "1")
+	ledger.expect(bob, "[]", "CollectionGetTokenEndorsementPolicy", "deeds", "7")
+
+	ledger.ok(admin, "CollectionSetTokenEndorsementPolicy", "deeds", "7", `["Org3MSP"]`)
+	ledger.expect(bob, `["Org3MSP"]`, "CollectionGetTokenEndorsementPolicy", "deeds", "7")
+	ledger.expect(bob, `["Org1MSP","Org2MSP"]`, "GetTokenEndorsementPolicy", "1")
+
+	// An empty list leaves only the chaincode-wide policy
+	ledger.ok(admin, "SetTokenEndorsementPolicy", "1", `[]`)
+	ledger.expect(bob, "[]", "GetTokenEndorsementPolicy", "1")
+	ledger.expect(bob, `["Org3MSP"]`, "CollectionGetTokenEndorsementPolicy", "deeds", "7")
+}
@@ -0,0 +1,348 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`Fractionalize` is invoke fnc that locks a non-fungible token into the contract
+and issues totalShares shares of it to the current owner
+*/
+func (c *TokenERC721Contract) Fractionalize(ctx contractapi.TransactionContextInterface, tokenId string, totalShares int) (*model.Fraction, error) {
+
+	if totalShares < 1 {
+		return nil, invalidArgumentError("totalShares must be a positive number")
+	}
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nft, err := _repository(ctx).GetNFT(LegacyCollectionID, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Is(nft.Owner) {
+		return nil, unauthorizedError("non-fungible token %s is not owned by %s", tokenId, client.ID)
+	}
+	owner := nft.Owner
+
+	err = _checkNotFrozen(ctx, LegacyCollectionID, tokenId, owner, client.ID, client.LegacyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the token into the vault, clearing any single-token approval
+	nft.Owner = FractionVaultAddress
+	nft.Approved = ""
+
+	err = _repository(ctx).PutNFT(nft)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _repository(ctx).MoveBalance(LegacyCollectionID, owner, FractionVaultAddress, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	fraction := model.NewFraction(tokenId, client.ID, totalShares)
+
+	fractionKey, err := ctx.GetStub().CreateCompositeKey(fractionPrefix, []string{tokenId})
+	if err != nil {
+		return nil, internalError("failed to CreateCompositeKey fractionKey: %v", err)
+	}
+
+	fractionBytes, err := json.Marshal(fraction)
+	if err != nil {
+		return nil, internalError("failed to marshal fraction: %v", err)
+	}
+
+	err = _repository(ctx).putState(fractionKey, fractionBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _putShares(ctx, tokenId, client.ID, totalShares)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit a single event for the transfer into the vault and the issued shares
+	transferEvent := model.NewTransferMetadata(owner, FractionVaultAddress, tokenId)
+
+	err = _recordActivity(ctx, transferEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _emitFractionEvent(ctx, FractionalizedEventKey, transferEvent, model.NewShareTransferMetadata(ZeroAddress, client.ID, tokenId, totalShares))
+	if err != nil {
+		return nil, err
+	}
+
+	return fraction, nil
+}
+
+/*
+`TransferShares` is invoke fnc that moves amount shares of a fractionalized token
+from the sender to the recipient
+*/
+func (c *TokenERC721Contract) TransferShares(ctx contractapi.TransactionContextInterface, tokenId string, to string, amount int) (bool, error) {
+
+	if amount < 1 {
+		return false, invalidArgumentError("amount must be a positive number")
+	}
+
+	err := _validateRecipient(ctx, to)
+	if err != nil {
+		return false, err
+	}
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return false, err
+	}
+	sender := client.ID
+
+	fractionalized, err := _isFractionalized(ctx, tokenId)
+	if err != nil {
+		return false, err
+	}
+	if !fractionalized {
+		return false, conflictError("non-fungible token %s is not fractionalized", tokenId)
+	}
+
+	err = _checkNotFrozen(ctx, LegacyCollectionID, tokenId, sender, client.LegacyID, to)
+	if err != nil {
+		return false, err
+	}
+
+	senderShares, err := _readShares(ctx, tokenId, sender)
+	if err != nil {
+		return false, err
+	}
+	if senderShares < amount {
+		return false, newContractError(ErrCodeInsufficientShares, "insufficient shares of %s: have %d, want %d", tokenId, senderShares, amount)
+	}
+
+	if sender == to {
+		return true, nil
+	}
+
+	recipientShares, err := _readShares(ctx, tokenId, to)
+	if err != nil {
+		return false, err
+	}
+
+	err = _putShares(ctx, tokenId, sender, senderShares-amount)
+	if err != nil {
+		return false, err
+	}
+
+	err = _putShares(ctx, tokenId, to, recipientShares+amount)
+	if err != nil {
+		return false, err
+	}
+
+	// Emit the ShareTransfer event
+	shareEventBytes, err := json.Marshal(model.NewShareTransferMetadata(sender, to, tokenId, amount))
+	if err != nil {
+		return false, internalError("failed to marshal shareEventBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(ShareTransferEventKey, shareEventBytes)
+	if err != nil {
+		return false, internalError("failed to SetEvent shareEventBytes %s: %v", shareEventBytes, err)
+	}
+
+	return true, nil
+}
+
+/*
+`SharesOf` is query fnc that returns the number of shares of a fractionalized token held by account
+*/
+func (c *TokenERC721Contract) SharesOf(ctx contractapi.TransactionContextInterface, tokenId string, account string) (int, error) {
+
+	return _readShares(ctx, tokenId, account)
+}
+
+/*
+`Redeem` is invoke fnc that gives a fractionalized token back to the sender
+once the sender has collected all of its shares
+*/
+func (c *TokenERC721Contract) Redeem(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return false, err
+	}
+	sender := client.ID
+
+	fraction, err := _readFraction(ctx, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	err = _checkNotFrozen(ctx, LegacyCollectionID, tokenId, sender, client.LegacyID)
+	if err != nil {
+		return false, err
+	}
+
+	senderShares, err := _readShares(ctx, tokenId, sender)
+	if err != nil {
+		return false, err
+	}
+	if senderShares != fraction.TotalShares {
+		return false, newContractError(ErrCodeInsufficientShares, "all %d shares of %s are required to redeem, sender holds %d", fraction.TotalShares, tokenId, senderShares)
+	}
+
+	// Burn the shares and release the token from the vault
+	err = _putShares(ctx, tokenId, sender, 0)
+	if err != nil {
+		return false, err
+	}
+
+	fractionKey, err := ctx.GetStub().CreateCompositeKey(fractionPrefix, []string{tokenId})
+	if err != nil {
+		return false, internalError("failed to CreateCompositeKey fractionKey: %v", err)
+	}
+
+	err = _repository(ctx).delState(fractionKey)
+	if err != nil {
+		return false, err
+	}
+
+	nft, err := _repository(ctx).GetNFT(LegacyCollectionID, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	nft.Owner = sender
+
+	err = _repository(ctx).PutNFT(nft)
+	if err != nil {
+		return false, err
+	}
+
+	err = _repository(ctx).MoveBalance(LegacyCollectionID, FractionVaultAddress, sender, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	// Emit a single event for the burned shares and the transfer out of the vault
+	transferEvent := model.NewTransferMetadata(FractionVaultAddress, sender, tokenId)
+
+	err = _recordActivity(ctx, transferEvent)
+	if err != nil {
+		return false, err
+	}
+
+	err = _emitFractionEvent(ctx, RedeemedEventKey, transferEvent, model.NewShareTransferMetadata(sender, ZeroAddress, tokenId, senderShares))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func _emitFractionEvent(ctx contractapi.TransactionContextInterface, eventKey string, transfer *model.Transfer, shares *model.ShareTransfer) error {
+	fractionEventBytes, err := json.Marshal(model.NewFractionEvent(transfer, shares))
+	if err != nil {
+		return internalError("failed to marshal fractionEventBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(eventKey, fractionEventBytes)
+	if err != nil {
+		return internalError("failed to SetEvent fractionEventBytes %s: %v", fractionEventBytes, err)
+	}
+
+	return nil
+}
+
+func _readFraction(ctx contractapi.TransactionContextInterface, tokenId string) (*model.Fraction, error) {
+	fractionKey, err := ctx.GetStub().CreateCompositeKey(fractionPrefix, []string{tokenId})
+	if err != nil {
+		return nil, internalError("failed to CreateCompositeKey %s: %v", tokenId, err)
+	}
+
+	fractionBytes, err := _repository(ctx).getState(fractionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(fractionBytes) == 0 {
+		return nil, conflictError("non-fungible token %s is not fractionalized", tokenId)
+	}
+
+	fraction := model.NewFraction("", "", 0)
+	err = json.Unmarshal(fractionBytes, fraction)
+	if err != nil {
+		return nil, internalError("failed to Unmarshal fractionBytes: %v", err)
+	}
+
+	return fraction, nil
+}
+
+func _isFractionalized(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+	fractionKey, err := ctx.GetStub().CreateCompositeKey(fractionPrefix, []string{tokenId})
+	if err != nil {
+		return false, internalError("failed to CreateCompositeKey %s: %v", tokenId, err)
+	}
+
+	fractionBytes, err := _repository(ctx).getState(fractionKey)
+	if err != nil {
+		return false, err
+	}
+
+	return len(fractionBytes) > 0, nil
+}
+
+func _readShares(ctx contractapi.TransactionContextInterface, tokenId string, account string) (int, error) {
+	shareKey, err := ctx.GetStub().CreateCompositeKey(sharePrefix, []string{tokenId, account})
+	if err != nil {
+		return 0, internalError("failed to CreateCompositeKey shareKey: %v", err)
+	}
+
+	shareBytes, err := _repository(ctx).getState(shareKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(shareBytes) == 0 {
+		return 0, nil
+	}
+
+	shares, err := strconv.Atoi(string(shareBytes))
+	if err != nil {
+		return 0, internalError("failed to parse shares %s: %v", shareBytes, err)
+	}
+
+	return shares, nil
+}
+
+// _putShares stores the share balance of account, removing the key once it drops to zero
+func _putShares(ctx contractapi.TransactionContextInterface, tokenId string, account string, shares int) error {
+	shareKey, err := ctx.GetStub().CreateCompositeKey(sharePrefix, []string{tokenId, account})
+	if err != nil {
+		return internalError("failed to CreateCompositeKey shareKey: %v", err)
+	}
+
+	if shares == 0 {
+		err = _repository(ctx).delState(shareKey)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	err = _repository(ctx).putState(shareKey, []byte(strconv.Itoa(shares)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
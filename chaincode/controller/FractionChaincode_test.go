@@ -0,0 +1,173 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+func TestFractionalize(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+
+	tests := []struct {
+		name        string
+		client      testClient
+		tokenId     string
+		totalShares string
+		want        ErrorCode
+	}{
+		{"non-positive shares", admin, "1", "0", ErrCodeInvalidArgument},
+		{"unknown token", admin, "2", "10", ErrCodeTokenNotFound},
+		{"not the owner", bob, "1", "10", ErrCodeUnauthorized},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "Fractionalize", string(test.want), test.tokenId, test.totalShares)
+		})
+	}
+
+	ledger.ok(admin, "Fractionalize", "1", "10")
+
+	if ledger.event().EventName != FractionalizedEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, FractionalizedEventKey)
+	}
+	fractionEvent := &model.FractionEvent{}
+	if err := json.Unmarshal(ledger.event().Payload, fractionEvent); err != nil {
+		t.Fatal(err)
+	}
+	if *fractionEvent.Transfer != *model.NewTransferMetadata(adminID, FractionVaultAddress, "1") {
+		t.Fatalf("transfer %+v", fractionEvent.Transfer)
+	}
+	if *fractionEvent.Shares != *model.NewShareTransferMetadata(ZeroAddress, adminID, "1", 10) {
+		t.Fatalf("shares %+v", fractionEvent.Shares)
+	}
+
+	ledger.expect(admin, FractionVaultAddress, "OwnerOf", "1")
+	ledger.expect(admin, "10", "SharesOf", "1", adminID)
+	ledger.fail(admin, "Fractionalize", string(ErrCodeUnauthorized), "1", "10")
+	ledger.fail(admin, "TransferFrom", "fractionalized", adminID, bobID, "1")
+	ledger.fail(admin, "TransferFrom", "fractionalized", FractionVaultAddress, bobID, "1")
+	ledger.fail(admin, "Burn", string(ErrCodeUnauthorized), "1")
+}
+
+func TestTransferShares(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+	ledger.ok(admin, "Fractionalize", "1", "10")
+
+	tests := []struct {
+		name    string
+		client  testClient
+		tokenId string
+		to      string
+		amount  string
+		want    ErrorCode
+	}{
+		{"non-positive amount", admin, "1", bobID, "0", ErrCodeInvalidArgument},
+		{"zero address", admin, "1", ZeroAddress, "1", ErrCodeInvalidArgument},
+		{"not fractionalized", admin, "2", bobID, "1", ErrCodeConflict},
+		{"more than held", admin, "1", bobID, "11", ErrCodeInsufficientShares},
+		{"no shares held", bob, "1", adminID, "1", ErrCodeInsufficientShares},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "TransferShares", string(test.want), test.tokenId, test.to, test.amount)
+		})
+	}
+
+	ledger.ok(admin, "TransferShares", "1", bobID, "4")
+
+	if ledger.event().EventName != ShareTransferEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, ShareTransferEventKey)
+	}
+	ledger.expect(admin, "6", "SharesOf", "1", adminID)
+	ledger.expect(admin, "4", "SharesOf", "1", bobID)
+}
+
+func TestRedeem(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "Fractionalize", "1", "10")
+	ledger.ok(admin, "TransferShares", "1", bobID, "4")
+
+	ledger.fail(bob, "Redeem", string(ErrCodeInsufficientShares), "1")
+	ledger.ok(admin, "TransferShares", "1", bobID, "6")
+	ledger.ok(bob, "Redeem", "1")
+
+	if ledger.event().EventName != RedeemedEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, RedeemedEventKey)
+	}
+	fractionEvent := &model.FractionEvent{}
+	if err := json.Unmarshal(ledger.event().Payload, fractionEvent); err != nil {
+		t.Fatal(err)
+	}
+	if *fractionEvent.Transfer != *model.NewTransferMetadata(FractionVaultAddress, bobID, "1") {
+		t.Fatalf("transfer %+v", fractionEvent.Transfer)
+	}
+	if *fractionEvent.Shares != *model.NewShareTransferMetadata(bobID, ZeroAddress, "1", 10) {
+		t.Fatalf("shares %+v", fractionEvent.Shares)
+	}
+
+	ledger.expect(bob, bobID, "OwnerOf", "1")
+	ledger.expect(bob, "1", "BalanceOf", bobID)
+	ledger.expect(bob, "0", "BalanceOf", FractionVaultAddress)
+	ledger.expect(bob, "0", "SharesOf", "1", bobID)
+	ledger.fail(bob, "Redeem", string(ErrCodeConflict), "1")
+	ledger.ok(bob, "TransferFrom", bobID, adminID, "1")
+}
+
+func TestFrozenFractions(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+	ledger.ok(admin, "Fractionalize", "2", "10")
+	ledger.ok(admin, "TransferShares", "2", bobID, "4")
+
+	tests := []struct {
+		name     string
+		hold     string
+		release  string
+		target   string
+		client   testClient
+		function string
+		args     []string
+	}{
+		{"fractionalize frozen token", "FreezeToken", "UnfreezeToken", "1", admin, "Fractionalize", []string{"1", "10"}},
+		{"fractionalize by frozen owner", "FreezeAccount", "UnfreezeAccount", adminID, admin, "Fractionalize", []string{"1", "10"}},
+		{"transfer shares of frozen token", "FreezeToken", "UnfreezeToken", "2", bob, "TransferShares", []string{"2", adminID, "1"}},
+		{"transfer shares by frozen sender", "FreezeAccount", "UnfreezeAccount", bobID, bob, "TransferShares", []string{"2", adminID, "1"}},
+		{"transfer shares to frozen recipient", "FreezeAccount", "UnfreezeAccount", adminID, bob, "TransferShares", []string{"2", adminID, "1"}},
+		{"redeem frozen token", "FreezeToken", "UnfreezeToken", "2", bob, "Redeem", []string{"2"}},
+		{"redeem by frozen sender", "FreezeAccount", "UnfreezeAccount", bobID, bob, "Redeem", []string{"2"}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.ok(admin, test.hold, test.target, "court order")
+			ledger.fail(test.client, test.function, string(ErrCodeFrozen), test.args...)
+			ledger.ok(admin, test.release, test.target, "released")
+		})
+	}
+
+	// Released holds no longer block the fraction
+	ledger.ok(admin, "Fractionalize", "1", "10")
+	ledger.ok(admin, "TransferShares", "2", bobID, "6")
+	ledger.ok(bob, "Redeem", "2")
+	ledger.expect(bob, bobID, "OwnerOf", "2")
+}
@@ -0,0 +1,150 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// clientAccount is the calling client in both account formats.
+// ID is the canonical account, LegacyID the decoded `x509::CN=...::CN=...` string
+// that was stored as owner before canonical accounts were introduced.
+type clientAccount struct {
+	ID       string
+	LegacyID string
+}
+
+// Is reports whether account refers to the client in either format
+func (a *clientAccount) Is(account string) bool {
+	return account != "" && (account == a.ID || account == a.LegacyID)
+}
+
+/*
+`ClientAccountID` is query fnc that returns the canonical account ID of the requesting client
+*/
+func (c *TokenERC721Contract) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return client.ID, nil
+}
+
+// _getClientAccount returns the calling client's account, cached on the TransactionContext after the first call
+func _getClientAccount(ctx contractapi.TransactionContextInterface) (*clientAccount, error) {
+	transactionContext, ok := ctx.(*TransactionContext)
+	if ok && transactionContext.account != nil {
+		return transactionContext.account, nil
+	}
+
+	account, err := _resolveClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		transactionContext.account = account
+	}
+
+	return account, nil
+}
+
+// _resolveClientAccount resolves the calling client into its canonical and legacy account IDs
+func _resolveClientAccount(ctx contractapi.TransactionContextInterface) (*clientAccount, error) {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return nil, internalError("failed to get client certificate: %v", err)
+	}
+	if cert == nil {
+		return nil, unauthorizedError("client identity has no X.509 certificate")
+	}
+
+	id64, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, internalError("failed to GetClientIdentity: %v", err)
+	}
+
+	legacyIDBytes, err := base64.StdEncoding.DecodeString(id64)
+	if err != nil {
+		return nil, internalError("failed to DecodeString client ID: %v", err)
+	}
+
+	accountID, err := canonicalAccountID(clientMSPID, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientAccount{ID: accountID, LegacyID: string(legacyIDBytes)}, nil
+}
+
+// canonicalAccountID is the hex encoded sha256 of the MSP ID followed by the certificate's public key.
+// It survives certificate renewal with the same key and does not leak issuer DNs.
+func canonicalAccountID(mspID string, cert *x509.Certificate) (string, error) {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil {
+		return "", internalError("failed to marshal client public key: %v", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(mspID))
+	hash.Write([]byte{0})
+	hash.Write(publicKeyBytes)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// _accountAliases returns account together with the canonical ID recorded for it
+// when account is a legacy ID of a client that has already used a canonical one
+func _accountAliases(ctx contractapi.TransactionContextInterface, account string) ([]string, error) {
+	aliasKey, err := ctx.GetStub().CreateCompositeKey(accountAliasPrefix, []string{account})
+	if err != nil {
+		return nil, internalError("failed to CreateCompositeKey aliasKey: %v", err)
+	}
+
+	aliasBytes, err := _repository(ctx).getState(aliasKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliasBytes) == 0 {
+		return []string{account}, nil
+	}
+
+	return []string{account, string(aliasBytes)}, nil
+}
+
+// _recordAccountAlias links the legacy ID of the client to its canonical ID,
+// so state keyed by the canonical ID also applies to tokens still owned by the legacy ID.
+// The activity indexed under the legacy ID before it was linked moves to the canonical ID.
+func _recordAccountAlias(ctx contractapi.TransactionContextInterface, client *clientAccount) error {
+	aliasKey, err := ctx.GetStub().CreateCompositeKey(accountAliasPrefix, []string{client.LegacyID})
+	if err != nil {
+		return internalError("failed to CreateCompositeKey aliasKey: %v", err)
+	}
+
+	repository := _repository(ctx)
+
+	aliasBytes, err := repository.getState(aliasKey)
+	if err != nil {
+		return err
+	}
+	if string(aliasBytes) == client.ID {
+		return nil
+	}
+
+	err = repository.putState(aliasKey, []byte(client.ID))
+	if err != nil {
+		return err
+	}
+
+	return _moveActivity(ctx, client.LegacyID, client.ID)
+}
@@ -0,0 +1,90 @@
+package chaincode
+
+import (
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+func TestClientAccountID(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	tests := []struct {
+		name    string
+		account string
+	}{
+		{"admin", adminID},
+		{"bob", bobID},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			if len(test.account) != 64 {
+				t.Fatalf("account %s is not a hex encoded sha256", test.account)
+			}
+		})
+	}
+
+	if adminID == bobID {
+		t.Fatal("different clients share an account")
+	}
+	ledger.expect(admin, adminID, "ClientAccountID")
+}
+
+func TestLegacyAccountOwnership(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	carol := newTestClient(t, "Org2MSP", "carol")
+	adminID := ledger.account(admin)
+	bobLegacyID, carolLegacyID := "x509::CN=bob::CN=bob", "x509::CN=carol::CN=carol"
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+	ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "1")
+	ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "2")
+
+	ledger.expect(bob, "2", "ClientAccountBalance")
+	ledger.ok(bob, "TransferFrom", ledger.account(bob), adminID, "1")
+
+	ledger.ok(bob, "SetApprovalForAll", carolLegacyID, "true")
+	ledger.ok(carol, "TransferFrom", bobLegacyID, adminID, "2")
+	ledger.fail(carol, "TransferFrom", string(ErrCodeUnauthorized), adminID, bobLegacyID, "2")
+}
+
+func TestRevokeLegacyOperatorApproval(t *testing.T) {
+	bobLegacyID, carolLegacyID := "x509::CN=bob::CN=bob", "x509::CN=carol::CN=carol"
+
+	tests := []struct {
+		name           string
+		legacyOperator bool
+	}{
+		{"legacy operator", true},
+		{"canonical operator", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ledger, admin := newInitializedLedger(t)
+			bob := newTestClient(t, "Org2MSP", "bob")
+			carol := newTestClient(t, "Org2MSP", "carol")
+			adminID := ledger.account(admin)
+			operator := ledger.account(carol)
+			if test.legacyOperator {
+				operator = carolLegacyID
+			}
+
+			// The grant was stored under the legacy ID before canonical accounts were introduced
+			ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+			ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "1")
+			ledger.seed(approvalPrefix, []string{bobLegacyID, operator}, model.NewApproval(bobLegacyID, operator, true))
+
+			// Migrating records the alias of the legacy ID
+			ledger.ok(bob, "SetApprovalForAll", adminID, "true")
+			ledger.ok(bob, "SetApprovalForAll", operator, "false")
+
+			ledger.fail(carol, "TransferFrom", string(ErrCodeUnauthorized), bobLegacyID, adminID, "1")
+			ledger.expect(bob, bobLegacyID, "OwnerOf", "1")
+		})
+	}
+}
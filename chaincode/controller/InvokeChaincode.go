@@ -1,9 +1,7 @@
 package chaincode
 
 import (
-	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"hyperledger_erc721/chaincode/model"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -14,108 +12,131 @@ import (
 from is the owner's address, to is reciepient's address
 */
 func (c *TokenERC721Contract) TransferFrom(ctx contractapi.TransactionContextInterface, from, to, tokenId string) (bool, error) {
+	return c.transferFrom(ctx, LegacyCollectionID, from, to, tokenId)
+}
+
+func (c *TokenERC721Contract) transferFrom(ctx contractapi.TransactionContextInterface, collectionId, from, to, tokenId string) (bool, error) {
 
-	initialized, err := checkInitialized(ctx)
+	var transferred bool
+	request, replayed, err := _replayRequest(ctx, &transferred)
 
 	if err != nil {
 		return false, err
 	}
 
-	if !initialized {
-		return false, fmt.Errorf("initialized first")
+	if replayed {
+		return transferred, nil
 	}
 
-	sender64, err := ctx.GetClientIdentity().GetID()
+	err = _validateRecipient(ctx, to)
 
 	if err != nil {
-		return false, fmt.Errorf("failed to GetClientIdentity : %v", err)
+		return false, err
 	}
 
-	senderBytes, err := base64.StdEncoding.DecodeString(sender64)
+	sender, err := _getClientAccount(ctx)
 
 	if err != nil {
-		return false, fmt.Errorf("failed to DecodeString sender ID : %v", err)
+		return false, err
 	}
 
-	sender := string(senderBytes)
+	repository := _repository(ctx)
 
-	nft, err := _readNFT(ctx, tokenId)
+	nft, err := repository.GetNFT(collectionId, tokenId)
 
 	if err != nil {
-		return false, fmt.Errorf("failed to _readNFT : %v", err)
+		return false, err
+	}
+
+	if collectionId == LegacyCollectionID {
+		fractionalized, err := _isFractionalized(ctx, tokenId)
+
+		if err != nil {
+			return false, err
+		}
+
+		if fractionalized {
+			return false, conflictError("non-fungible token %s is fractionalized, redeem it first", tokenId)
+		}
 	}
 
 	owner := nft.Owner
 	operator := nft.Approved
-	operatorApproval, err := c.IsApprovedForAll(ctx, owner, sender)
+
+	err = _checkNotFrozen(ctx, collectionId, tokenId, owner, to, sender.ID, sender.LegacyID)
 
 	if err != nil {
-		return false, fmt.Errorf("failed to get IsApprovedForAll : %v", err)
+		return false, err
 	}
 
-	if owner != sender && operator != sender && !operatorApproval {
-		return false, fmt.Errorf("the sender is not the current owner nor an authorized operator")
+	if !sender.Is(owner) && !sender.Is(operator) {
+		grant, err := c.clientOperatorGrant(ctx, collectionId, owner, sender, tokenId)
+
+		if err != nil {
+			return false, err
+		}
+
+		if grant == nil {
+			return false, unauthorizedError("the sender is not the current owner nor an authorized operator")
+		}
+
+		// Transfers made through a limited grant use it up
+		if grant.MaxTransfers > 0 {
+			grant.Transfers++
+
+			err = repository.PutApproval(grant)
+
+			if err != nil {
+				return false, err
+			}
+		}
 	}
 
-	// Check if `from` is the current owner
-	if owner != from {
-		return false, fmt.Errorf("the from is not the current owner")
+	// Check if `from` is the current owner, the sender may name itself in either account format
+	if owner != from && !(sender.Is(owner) && sender.Is(from)) {
+		return false, conflictError("the from is not the current owner")
 	}
+	from = owner
 
 	// Clear the approved client for this non-fungible token
 	nft.Approved = ""
 
 	// Overwrite a non-fungible token to assign a new owner.
 	nft.Owner = to
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
-
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey: %v", err)
-	}
-
-	nftBytes, err := json.Marshal(nft)
-	if err != nil {
-		return false, fmt.Errorf("failed to marshal approval: %v", err)
-	}
 
-	err = ctx.GetStub().PutState(nftKey, nftBytes)
+	err = repository.PutNFT(nft)
 	if err != nil {
-		return false, fmt.Errorf("failed to PutState nftBytes %s: %v", nftBytes, err)
+		return false, err
 	}
 
-	// Remove a composite key from the balance of the current owner
-	balanceKeyFrom, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{from, tokenId})
+	// Move the token from the balance of the current owner to the balance of the new owner
+	err = repository.MoveBalance(collectionId, from, to, tokenId)
 	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey from: %v", err)
+		return false, err
 	}
 
-	err = ctx.GetStub().DelState(balanceKeyFrom)
-	if err != nil {
-		return false, fmt.Errorf("failed to DelState balanceKeyFrom %s: %v", nftBytes, err)
-	}
+	// Emit the Transfer event
+	transferEvent := model.NewTransferMetadata(from, to, tokenId)
+	transferEvent.CollectionId = collectionId
 
-	// Save a composite key to count the balance of a new owner
-	balanceKeyTo, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{to, tokenId})
+	err = _recordActivity(ctx, transferEvent)
 	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey to: %v", err)
+		return false, err
 	}
 
-	err = ctx.GetStub().PutState(balanceKeyTo, []byte{0})
+	transferEventBytes, err := json.Marshal(transferEvent)
 	if err != nil {
-		return false, fmt.Errorf("failed to PutState balanceKeyTo %s: %v", balanceKeyTo, err)
+		return false, internalError("failed to marshal transferEventBytes: %v", err)
 	}
 
-	// Emit the Transfer event
-	transferEvent := model.NewTransferMetadata(from, to, tokenId)
-
-	transferEventBytes, err := json.Marshal(transferEvent)
+	err = ctx.GetStub().SetEvent(TransferEventKey, transferEventBytes)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal transferEventBytes: %v", err)
+		return false, internalError("failed to SetEvent transferEventBytes %s: %v", transferEventBytes, err)
 	}
 
-	err = ctx.GetStub().SetEvent(TransferEventKey, transferEventBytes)
+	err = _completeRequest(ctx, request, true)
 	if err != nil {
-		return false, fmt.Errorf("failed to SetEvent transferEventBytes %s: %v", transferEventBytes, err)
+		return false, err
 	}
 
 	return true, nil
@@ -125,82 +146,104 @@ func (c *TokenERC721Contract) TransferFrom(ctx contractapi.TransactionContextInt
 `MintWithTokenURI`is invoke fnc that mint a new non-fungible token
 */
 func (c *TokenERC721Contract) MintWithTokenURI(ctx contractapi.TransactionContextInterface, tokenId string, tokenURI string) (*model.NFT, error) {
+	return c.mintWithTokenURI(ctx, LegacyCollectionID, tokenId, tokenURI, "", "")
+}
+
+func (c *TokenERC721Contract) mintWithTokenURI(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string, contentHash string, attributes string) (*model.NFT, error) {
 
-	initialized, err := checkInitialized(ctx)
+	nft := new(model.NFT)
+	request, replayed, err := _replayRequest(ctx, nft)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
+		return nil, err
 	}
-	if !initialized {
-		return nil, fmt.Errorf("first initialized")
+	if replayed {
+		return nft, nil
 	}
 
-	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	client, err := _getClientAccount(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get clientMSPID: %v", err)
+		return nil, err
 	}
 
-	if clientMSPID != "Org1MSP" {
-		return nil, fmt.Errorf("client is not authorized to set the name and symbol of the token")
-	}
-
-	minter64, err := ctx.GetClientIdentity().GetID()
+	nft, err = _mint(ctx, collectionId, tokenId, tokenURI, contentHash, attributes, client.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get minter id: %v", err)
+		return nil, err
 	}
 
-	minterBytes, err := base64.StdEncoding.DecodeString(minter64)
+	err = _completeRequest(ctx, request, nft)
 	if err != nil {
-		return nil, fmt.Errorf("failed to DecodeString minter64: %v", err)
+		return nil, err
 	}
 
-	minter := string(minterBytes)
+	return nft, nil
+}
 
-	exists := _nftExists(ctx, tokenId)
+// _mint creates tokenId owned by minter and emits its Transfer event.
+// contentHash is the multihash anchoring the document at tokenURI, empty when none is anchored.
+// attributes is the JSON document of the on-chain attributes, validated against the metadata schema of the collection.
+func _mint(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string, contentHash string, attributes string, minter string) (*model.NFT, error) {
 
-	if exists {
-		return nil, fmt.Errorf("the token %s is already minted", tokenId)
+	repository := _repository(ctx)
+
+	exists, err := repository.NFTExists(collectionId, tokenId)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add a non-fungible token
-	nft := model.NewNFT(tokenId, minter, tokenURI, "")
+	if exists {
+		return nil, newContractError(ErrCodeAlreadyMinted, "the token %s is already minted", tokenId)
+	}
 
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
+	attributes, err = _validateAttributes(ctx, collectionId, attributes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to CreateCompositeKey to nftKey: %v", err)
+		return nil, err
 	}
 
-	nftBytes, err := json.Marshal(nft)
+	err = _countMint(ctx, collectionId, minter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal nft: %v", err)
+		return nil, err
 	}
 
-	err = ctx.GetStub().PutState(nftKey, nftBytes)
+	// Add a non-fungible token
+	nft := model.NewNFT(tokenId, minter, tokenURI, "")
+	nft.CollectionId = collectionId
+	nft.Version = model.CurrentSchemaVersion
+	nft.ContentHash = contentHash
+	nft.Attributes = attributes
+
+	err = repository.PutNFT(nft)
 	if err != nil {
-		return nil, fmt.Errorf("failed to PutState nftBytes %s: %v", nftBytes, err)
+		return nil, err
 	}
 
 	// increase balance
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{minter, tokenId})
+	err = repository.PutBalance(collectionId, minter, tokenId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to CreateCompositeKey to balanceKey: %v", err)
+		return nil, err
 	}
 
-	err = ctx.GetStub().PutState(balanceKey, []byte{'\u0000'})
+	err = repository.putState(MintedKey, []byte{'\u0000'})
 	if err != nil {
-		return nil, fmt.Errorf("failed to PutState balanceKey %s: %v", nftBytes, err)
+		return nil, err
 	}
 
 	// Emit the Transfer event
-	transferEvent := model.NewTransferMetadata("0x0", minter, tokenId)
+	transferEvent := model.NewTransferMetadata(ZeroAddress, minter, tokenId)
+	transferEvent.CollectionId = collectionId
+
+	err = _recordActivity(ctx, transferEvent)
+	if err != nil {
+		return nil, err
+	}
 
 	transferEventBytes, err := json.Marshal(transferEvent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal transferEventBytes: %v", err)
+		return nil, internalError("failed to marshal transferEventBytes: %v", err)
 	}
 
 	err = ctx.GetStub().SetEvent(TransferEventKey, transferEventBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to SetEvent transferEventBytes %s: %v", transferEventBytes, err)
+		return nil, internalError("failed to SetEvent transferEventBytes %s: %v", transferEventBytes, err)
 	}
 
 	return nft, nil
@@ -211,57 +254,49 @@ func (c *TokenERC721Contract) MintWithTokenURI(ctx contractapi.TransactionContex
 */
 
 func (c *TokenERC721Contract) Approve(ctx contractapi.TransactionContextInterface, operator string, tokenId string) (bool, error) {
+	return c.approve(ctx, LegacyCollectionID, operator, tokenId)
+}
 
-	initialized, err := checkInitialized(ctx)
-	if err != nil {
-		return false, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return false, fmt.Errorf("first initialize")
-	}
+func (c *TokenERC721Contract) approve(ctx contractapi.TransactionContextInterface, collectionId string, operator string, tokenId string) (bool, error) {
 
-	sender64, err := ctx.GetClientIdentity().GetID()
+	sender, err := _getClientAccount(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to GetClientIdentity: %v", err)
+		return false, err
 	}
 
-	senderBytes, err := base64.StdEncoding.DecodeString(sender64)
-	if err != nil {
-		return false, fmt.Errorf("failed to DecodeString senderBytes: %v", err)
-	}
-	sender := string(senderBytes)
+	repository := _repository(ctx)
 
-	nft, err := _readNFT(ctx, tokenId)
+	nft, err := repository.GetNFT(collectionId, tokenId)
 	if err != nil {
-		return false, fmt.Errorf("failed to _readNFT: %v", err)
+		return false, err
 	}
 
 	// Check if the sender is the current owner of the non-fungible token
 	// or an authorized operator of the current owner
 	owner := nft.Owner
-	operatorApproval, err := c.IsApprovedForAll(ctx, owner, sender)
+
+	err = _checkNotFrozen(ctx, collectionId, tokenId, owner, sender.ID, sender.LegacyID)
 	if err != nil {
-		return false, fmt.Errorf("failed to get IsApprovedForAll: %v", err)
+		return false, err
 	}
-	if owner != sender && !operatorApproval {
-		return false, fmt.Errorf("the sender is not the current owner nor an authorized operator")
+
+	if !sender.Is(owner) {
+		grant, err := c.clientOperatorGrant(ctx, collectionId, owner, sender, tokenId)
+		if err != nil {
+			return false, err
+		}
+		// A grant limited in transfers cannot hand out token approvals that would transfer past its limit
+		if grant == nil || grant.MaxTransfers > 0 {
+			return false, unauthorizedError("the sender is not the current owner nor an authorized operator")
+		}
 	}
 
 	// Update the approved operator of the non-fungible token
 	nft.Approved = operator
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey %s: %v", nftKey, err)
-	}
 
-	nftBytes, err := json.Marshal(nft)
+	err = repository.PutNFT(nft)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal nftBytes: %v", err)
-	}
-
-	err = ctx.GetStub().PutState(nftKey, nftBytes)
-	if err != nil {
-		return false, fmt.Errorf("failed to PutState for nftKey: %v", err)
+		return false, err
 	}
 
 	return true, nil
@@ -273,117 +308,144 @@ this is manage all the message sender's assets
 */
 
 func (c *TokenERC721Contract) SetApprovalForAll(ctx contractapi.TransactionContextInterface, operator string, approved bool) (bool, error) {
+	return c.setApprovalForAll(ctx, LegacyCollectionID, operator, approved)
+}
 
-	initialized, err := checkInitialized(ctx)
-	if err != nil {
-		return false, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return false, fmt.Errorf("please first initialize")
-	}
+func (c *TokenERC721Contract) setApprovalForAll(ctx contractapi.TransactionContextInterface, collectionId string, operator string, approved bool) (bool, error) {
 
-	sender64, err := ctx.GetClientIdentity().GetID()
+	client, err := _getClientAccount(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to GetClientIdentity: %v", err)
+		return false, err
 	}
+	sender := client.ID
+
+	nftApproval := model.NewApproval(sender, operator, approved)
+	nftApproval.CollectionId = collectionId
+	nftApproval.Version = model.CurrentSchemaVersion
 
-	senderBytes, err := base64.StdEncoding.DecodeString(sender64)
+	err = _putOperatorApproval(ctx, client, nftApproval)
 	if err != nil {
-		return false, fmt.Errorf("failed to DecodeString sender: %v", err)
+		return false, err
 	}
-	sender := string(senderBytes)
 
-	nftApproval := model.NewApproval(sender, operator, approved)
+	return true, nil
+}
 
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(approvalPrefix, []string{sender, operator})
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey: %v", err)
+// _putOperatorApproval stores an operator approval granted by the client and emits the ApprovalForAll event.
+// Revoked approvals are deleted rather than stored as not approved, under both account formats of the client.
+func _putOperatorApproval(ctx contractapi.TransactionContextInterface, client *clientAccount, nftApproval *model.Approval) error {
+	// Frozen accounts cannot grant operators, revoking a grant is always allowed
+	if nftApproval.Approved {
+		err := _checkNotFrozen(ctx, nftApproval.CollectionId, "", client.ID, client.LegacyID)
+		if err != nil {
+			return err
+		}
 	}
 
-	approvalBytes, err := json.Marshal(nftApproval)
+	// Tokens still owned by the legacy ID of the sender are covered through its alias
+	err := _recordAccountAlias(ctx, client)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal approvalBytes: %v", err)
+		return err
 	}
 
-	err = ctx.GetStub().PutState(approvalKey, approvalBytes)
+	if nftApproval.Approved {
+		err = _repository(ctx).PutApproval(nftApproval)
+		if err != nil {
+			return err
+		}
+	} else {
+		// A grant stored under the legacy ID would otherwise still be honoured through the alias
+		for _, owner := range []string{client.ID, client.LegacyID} {
+			err = _repository(ctx).DeleteApproval(nftApproval.CollectionId, owner, nftApproval.Operator)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	approvalBytes, err := json.Marshal(nftApproval)
 	if err != nil {
-		return false, fmt.Errorf("failed to PutState approvalBytes: %v", err)
+		return internalError("failed to marshal approvalBytes: %v", err)
 	}
 
-	err = ctx.GetStub().SetEvent("ApprovalForAll", approvalBytes)
+	err = ctx.GetStub().SetEvent(ApprovalForAllEventKey, approvalBytes)
 	if err != nil {
-		return false, fmt.Errorf("failed to SetEvent ApprovalForAll: %v", err)
+		return internalError("failed to SetEvent ApprovalForAll: %v", err)
 	}
 
-	return true, nil
+	return nil
 }
 
 /*
 `Burn` is invoke fnc that burn a non-fungible token
 */
 func (c *TokenERC721Contract) Burn(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+	return c.burn(ctx, LegacyCollectionID, tokenId)
+}
 
-	initialized, err := checkInitialized(ctx)
-	if err != nil {
-		return false, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return false, fmt.Errorf("please first initialize")
-	}
+func (c *TokenERC721Contract) burn(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (bool, error) {
 
-	owner64, err := ctx.GetClientIdentity().GetID()
+	client, err := _getClientAccount(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to GetClientIdentity owner64: %v", err)
+		return false, err
 	}
 
-	ownerBytes, err := base64.StdEncoding.DecodeString(owner64)
-	if err != nil {
-		return false, fmt.Errorf("failed to DecodeString owner64: %v", err)
-	}
-	owner := string(ownerBytes)
+	repository := _repository(ctx)
 
 	// Check if a caller is the owner of the non-fungible token
-	nft, err := _readNFT(ctx, tokenId)
+	nft, err := repository.GetNFT(collectionId, tokenId)
 	if err != nil {
-		return false, fmt.Errorf("failed to _readNFT nft : %v", err)
+		return false, err
 	}
-	if nft.Owner != owner {
-		return false, fmt.Errorf("non-fungible token %s is not owned by %s", tokenId, owner)
+	if !client.Is(nft.Owner) {
+		return false, unauthorizedError("non-fungible token %s is not owned by %s", tokenId, client.ID)
 	}
+	owner := nft.Owner
 
-	// Delete the token
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
+	err = _checkNotFrozen(ctx, collectionId, tokenId, owner, client.ID, client.LegacyID)
 	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey tokenId: %v", err)
+		return false, err
 	}
 
-	err = ctx.GetStub().DelState(nftKey)
-	if err != nil {
-		return false, fmt.Errorf("failed to DelState nftKey: %v", err)
+	if collectionId == LegacyCollectionID {
+		fractionalized, err := _isFractionalized(ctx, tokenId)
+		if err != nil {
+			return false, err
+		}
+		if fractionalized {
+			return false, conflictError("non-fungible token %s is fractionalized, redeem it first", tokenId)
+		}
 	}
 
-	// Remove a composite key from the balance of the owner
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(balancePrefix, []string{owner, tokenId})
+	// Delete the token
+	err = repository.DeleteNFT(collectionId, tokenId)
 	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey balanceKey %s: %v", balanceKey, err)
+		return false, err
 	}
 
-	err = ctx.GetStub().DelState(balanceKey)
+	// Remove the token from the balance of the owner
+	err = repository.DeleteBalance(collectionId, owner, tokenId)
 	if err != nil {
-		return false, fmt.Errorf("failed to DelState balanceKey %s: %v", balanceKey, err)
+		return false, err
 	}
 
 	// Emit the Transfer event
-	transferEvent := model.NewTransferMetadata(owner, "0x0", tokenId)
+	transferEvent := model.NewTransferMetadata(owner, ZeroAddress, tokenId)
+	transferEvent.CollectionId = collectionId
+
+	err = _recordActivity(ctx, transferEvent)
+	if err != nil {
+		return false, err
+	}
 
 	transferEventBytes, err := json.Marshal(transferEvent)
 	if err != nil {
-		return false, fmt.Errorf("failed to marshal transferEventBytes: %v", err)
+		return false, internalError("failed to marshal transferEventBytes: %v", err)
 	}
 
 	err = ctx.GetStub().SetEvent("Transfer", transferEventBytes)
 	if err != nil {
-		return false, fmt.Errorf("failed to SetEvent transferEventBytes: %v", err)
+		return false, internalError("failed to SetEvent transferEventBytes: %v", err)
 	}
 
 	return true, nil
@@ -0,0 +1,132 @@
+package chaincode
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ERC-165 identifiers of the interfaces every deployment implements
+var coreInterfaces = map[string]bool{
+	"0x01ffc9a7": true, // ERC-165
+	"0x80ac58cd": true, // ERC-721
+	"0x5b5e139f": true, // ERC-721 metadata
+}
+
+// Optional extensions a deployment may enable, with their ERC-165 identifier where one is standardized
+var optionalExtensions = map[string]string{
+	"enumerable": "0x780e9d63",
+	"royalty":    "0x2a55205a",
+	"rentable":   "0xad092b5c",
+	"soulbound":  "0xb45a3c0e",
+	"pausable":   "",
+}
+
+/*
+`SetContractURI` is invoke fnc that sets the URI of the collection-level metadata document
+*/
+func (c *TokenERC721Contract) SetContractURI(ctx contractapi.TransactionContextInterface, contractURI string) (bool, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.ContractURI = contractURI
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`ContractURI` is query fnc that returns the URI of the collection-level metadata document
+*/
+func (c *TokenERC721Contract) ContractURI(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return "", err
+	}
+
+	return *metadata.GetContractURI(), nil
+}
+
+/*
+`SetExtensionEnabled` is invoke fnc that declares an optional extension as enabled or disabled for this deployment
+*/
+func (c *TokenERC721Contract) SetExtensionEnabled(ctx contractapi.TransactionContextInterface, extension string, enabled bool) (bool, error) {
+
+	if _, ok := optionalExtensions[extension]; !ok {
+		return false, invalidArgumentError("unknown extension %s", extension)
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	extensions := []string{}
+	for _, enabledExtension := range metadata.Extensions {
+		if enabledExtension != extension {
+			extensions = append(extensions, enabledExtension)
+		}
+	}
+	if enabled {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	metadata.Extensions = extensions
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`SupportedExtensions` is query fnc that lists the optional extensions enabled for this deployment
+*/
+func (c *TokenERC721Contract) SupportedExtensions(ctx contractapi.TransactionContextInterface) ([]string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{}, metadata.Extensions...), nil
+}
+
+/*
+`SupportsInterface` is query fnc that reports whether the contract implements an interface.
+interfaceId is an ERC-165 identifier such as 0x80ac58cd, or the name of an optional extension such as pausable
+*/
+func (c *TokenERC721Contract) SupportsInterface(ctx contractapi.TransactionContextInterface, interfaceId string) (bool, error) {
+
+	interfaceId = strings.ToLower(interfaceId)
+	if interfaceId == "" {
+		return false, invalidArgumentError("interfaceId must not be empty")
+	}
+	if coreInterfaces[interfaceId] {
+		return true, nil
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, extension := range metadata.Extensions {
+		if extension == interfaceId || optionalExtensions[extension] == interfaceId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
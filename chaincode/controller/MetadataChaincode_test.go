@@ -0,0 +1,47 @@
+package chaincode
+
+import "testing"
+
+func TestContractURI(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.expect(admin, "", "ContractURI")
+	ledger.fail(bob, "SetContractURI", string(ErrCodeUnauthorized), "ipfs://contract")
+	ledger.ok(admin, "SetContractURI", "ipfs://contract")
+	ledger.expect(bob, "ipfs://contract", "ContractURI")
+	ledger.expect(bob, "HLF721", "Name")
+}
+
+func TestSupportsInterface(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+
+	ledger.fail(admin, "SetExtensionEnabled", string(ErrCodeInvalidArgument), "unknown", "true")
+	ledger.ok(admin, "SetExtensionEnabled", "royalty", "true")
+	ledger.ok(admin, "SetExtensionEnabled", "pausable", "true")
+	ledger.ok(admin, "SetExtensionEnabled", "pausable", "false")
+
+	tests := []struct {
+		name        string
+		interfaceId string
+		want        string
+	}{
+		{"ERC-165", "0x01ffc9a7", "true"},
+		{"ERC-721 in upper case", "0x80AC58CD", "true"},
+		{"ERC-721 metadata", "0x5b5e139f", "true"},
+		{"enabled extension by id", "0x2a55205a", "true"},
+		{"enabled extension by name", "royalty", "true"},
+		{"disabled extension by id", "0x780e9d63", "false"},
+		{"disabled extension by name", "pausable", "false"},
+		{"unknown interface", "0xffffffff", "false"},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.expect(admin, test.want, "SupportsInterface", test.interfaceId)
+		})
+	}
+
+	ledger.fail(admin, "SupportsInterface", string(ErrCodeInvalidArgument), "")
+	ledger.expect(admin, `["royalty"]`, "SupportedExtensions")
+}
@@ -0,0 +1,330 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"hyperledger_erc721/chaincode/model"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Versioned object types, in the order `PendingMigration` walks through them
+var migratedObjectTypes = []string{nftPrefix, approvalPrefix}
+
+// Width of the legacy collection keys of each versioned object type, collection keys carry one more attribute
+var legacyKeyWidths = map[string]int{nftPrefix: 1, approvalPrefix: 2}
+
+/*
+`SchemaVersion` is query fnc that returns the schema version of the records stored by this deployment
+*/
+func (c *TokenERC721Contract) SchemaVersion(ctx contractapi.TransactionContextInterface) (int, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return metadata.StoredSchemaVersion(), nil
+}
+
+/*
+`PendingMigration` is query fnc that scans at most pageSize nft and approval records of every collection
+and returns the keys of those still stored at fromVersion.
+Call it again with the returned bookmark until the bookmark is empty; each page resumes where the previous one stopped.
+Paginated queries are only allowed in read-only transactions, so the keys are rewritten by a separate `Migrate`.
+*/
+func (c *TokenERC721Contract) PendingMigration(ctx contractapi.TransactionContextInterface, fromVersion int, pageSize int, bookmark string) (*model.MigrationPage, error) {
+
+	if pageSize < 1 {
+		return nil, invalidArgumentError("pageSize must be a positive number")
+	}
+
+	objectType, queryBookmark, err := parseMigrationBookmark(bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	page := model.NewMigrationPage()
+
+	for i, migratedObjectType := range migratedObjectTypes {
+		if objectType != "" && migratedObjectType != objectType {
+			continue
+		}
+		objectType = ""
+
+		nextBookmark, scanned, err := _pendingMigrationKeys(ctx, page, migratedObjectType, fromVersion, pageSize, queryBookmark)
+		if err != nil {
+			return nil, err
+		}
+		queryBookmark = ""
+
+		if nextBookmark != "" {
+			page.Bookmark = migratedObjectType + ":" + base64.StdEncoding.EncodeToString([]byte(nextBookmark))
+			return page, nil
+		}
+
+		pageSize -= scanned
+		if pageSize == 0 {
+			// The next object type starts from its first key
+			if i+1 < len(migratedObjectTypes) {
+				page.Bookmark = migratedObjectTypes[i+1] + ":"
+			}
+			return page, nil
+		}
+	}
+
+	return page, nil
+}
+
+/*
+`Migrate` is invoke fnc that rewrites the nft and approval records stored under keys from fromVersion to toVersion.
+keys are the keys returned by `PendingMigration`; records already migrated or deleted in the meantime are skipped.
+*/
+func (c *TokenERC721Contract) Migrate(ctx contractapi.TransactionContextInterface, fromVersion int, toVersion int, keys []string) (*model.Migration, error) {
+
+	err := _checkMigration(ctx, fromVersion, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	migration := model.NewMigration(fromVersion, toVersion)
+
+	for _, key := range keys {
+		objectType, attributes, err := _splitMigratedKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := _repository(ctx).getState(key)
+		if err != nil {
+			return nil, err
+		}
+
+		migration.Scanned++
+
+		if len(value) == 0 {
+			continue
+		}
+
+		err = _migrateRecord(ctx, migration, objectType, attributes, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return migration, nil
+}
+
+/*
+`CompleteMigration` is invoke fnc that bumps the schema version of the deployment from fromVersion to toVersion
+once `PendingMigration` has no key left. It checks every nft and approval record in a single transaction.
+*/
+func (c *TokenERC721Contract) CompleteMigration(ctx contractapi.TransactionContextInterface, fromVersion int, toVersion int) (*model.Migration, error) {
+
+	err := _checkMigration(ctx, fromVersion, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	migration := model.NewMigration(fromVersion, toVersion)
+	pending := 0
+
+	for _, objectType := range migratedObjectTypes {
+		err = _repository(ctx).scanObjectType(objectType, func(key string, value []byte) error {
+			migration.Scanned++
+
+			version, err := _storedVersion(objectType, value)
+			if err != nil {
+				return err
+			}
+			if version == fromVersion {
+				pending++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pending > 0 {
+		return nil, conflictError("%d records are still at schema version %d", pending, fromVersion)
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.SchemaVersion = toVersion
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	migration.Done = true
+
+	return migration, nil
+}
+
+// _checkMigration rejects a migration that does not start from the schema version of the ledger or does not end at the current one
+func _checkMigration(ctx contractapi.TransactionContextInterface, fromVersion int, toVersion int) error {
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return err
+	}
+
+	if fromVersion < model.LegacySchemaVersion || fromVersion >= toVersion {
+		return invalidArgumentError("cannot migrate from version %d to version %d", fromVersion, toVersion)
+	}
+	if toVersion != model.CurrentSchemaVersion {
+		return invalidArgumentError("records can only be migrated to version %d", model.CurrentSchemaVersion)
+	}
+	if metadata.StoredSchemaVersion() != fromVersion {
+		return conflictError("the ledger is at schema version %d", metadata.StoredSchemaVersion())
+	}
+
+	return nil
+}
+
+// _pendingMigrationKeys reads one page of objectType records from queryBookmark and adds the keys of those at fromVersion to the page.
+// It returns the bookmark of the next page, empty once the object type is exhausted, and the number of records read.
+func _pendingMigrationKeys(ctx contractapi.TransactionContextInterface, page *model.MigrationPage, objectType string, fromVersion int, pageSize int, queryBookmark string) (string, int, error) {
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(objectType, []string{}, int32(pageSize), queryBookmark)
+	if err != nil {
+		return "", 0, internalError("failed to GetStateByPartialCompositeKeyWithPagination %s: %v", objectType, err)
+	}
+	defer iterator.Close()
+
+	scanned := 0
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return "", 0, internalError("failed to iterate %s: %v", objectType, err)
+		}
+		scanned++
+
+		version, err := _storedVersion(objectType, kv.Value)
+		if err != nil {
+			return "", 0, err
+		}
+		if version == fromVersion {
+			page.Keys = append(page.Keys, kv.Key)
+		}
+	}
+
+	// CouchDB returns a bookmark with the last page as well
+	if scanned < pageSize {
+		return "", scanned, nil
+	}
+
+	return metadata.GetBookmark(), scanned, nil
+}
+
+// _splitMigratedKey splits a composite key of a versioned object type, other keys are rejected before splitting
+func _splitMigratedKey(ctx contractapi.TransactionContextInterface, key string) (string, []string, error) {
+	for _, objectType := range migratedObjectTypes {
+		prefix, err := ctx.GetStub().CreateCompositeKey(objectType, []string{})
+		if err != nil {
+			return "", nil, internalError("failed to CreateCompositeKey %s: %v", objectType, err)
+		}
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "\x00") {
+			continue
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(key)
+		if err != nil {
+			return "", nil, invalidArgumentError("malformed key %q: %v", key, err)
+		}
+
+		return objectType, attributes, nil
+	}
+
+	return "", nil, invalidArgumentError("key %q is not an nft or approval key", key)
+}
+
+func _storedVersion(objectType string, value []byte) (int, error) {
+	if objectType == nftPrefix {
+		nft := model.NewNFT("", "", "", "")
+		err := model.UnmarshalNFT(value, nft)
+		if err != nil {
+			return 0, internalError("failed to Unmarshal %s: %v", objectType, err)
+		}
+		return nft.StoredVersion(), nil
+	}
+
+	approval := model.NewApproval("", "", false)
+	err := model.UnmarshalApproval(value, approval)
+	if err != nil {
+		return 0, internalError("failed to Unmarshal %s: %v", objectType, err)
+	}
+	return approval.StoredVersion(), nil
+}
+
+func _migrateRecord(ctx contractapi.TransactionContextInterface, migration *model.Migration, objectType string, attributes []string, value []byte) error {
+	// Collection keys carry the collection as an extra leading attribute
+	collectionId := LegacyCollectionID
+	if len(attributes) > legacyKeyWidths[objectType] {
+		collectionId = attributes[0]
+	}
+
+	repository := _repository(ctx)
+
+	var err error
+	if objectType == nftPrefix {
+		nft := model.NewNFT("", "", "", "")
+		err = model.UnmarshalNFT(value, nft)
+		if err != nil {
+			return internalError("failed to Unmarshal %s: %v", objectType, err)
+		}
+		if nft.StoredVersion() != migration.FromVersion {
+			return nil
+		}
+
+		nft.Upgrade(collectionId)
+
+		err = repository.PutNFT(nft)
+	} else {
+		approval := model.NewApproval("", "", false)
+		err = model.UnmarshalApproval(value, approval)
+		if err != nil {
+			return internalError("failed to Unmarshal %s: %v", objectType, err)
+		}
+		if approval.StoredVersion() != migration.FromVersion {
+			return nil
+		}
+
+		approval.Upgrade(collectionId)
+
+		err = repository.PutApproval(approval)
+	}
+	if err != nil {
+		return err
+	}
+
+	migration.Migrated++
+
+	return nil
+}
+
+// parseMigrationBookmark splits a bookmark of the form <objectType>:<base64 of the query bookmark of that object type>
+func parseMigrationBookmark(bookmark string) (string, string, error) {
+	if bookmark == "" {
+		return "", "", nil
+	}
+
+	separator := strings.Index(bookmark, ":")
+	if separator < 0 {
+		return "", "", invalidArgumentError("malformed bookmark %s", bookmark)
+	}
+
+	queryBookmark, err := base64.StdEncoding.DecodeString(bookmark[separator+1:])
+	if err != nil {
+		return "", "", invalidArgumentError("malformed bookmark %s", bookmark)
+	}
+
+	return bookmark[:separator], string(queryBookmark), nil
+}
@@ -0,0 +1,154 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"strconv"
+	"testing"
+)
+
+// downgradeToLegacySchema strips the version tags from the ledger, as records were stored before schema versioning
+func downgradeToLegacySchema(ledger *testLedger) {
+	for key, value := range ledger.stub.State {
+		record := map[string]interface{}{}
+		if json.Unmarshal(value, &record) != nil {
+			continue
+		}
+
+		delete(record, "version")
+		delete(record, "schemaVersion")
+		delete(record, "collectionId")
+
+		recordBytes, err := json.Marshal(record)
+		if err != nil {
+			ledger.t.Fatal(err)
+		}
+		ledger.stub.State[key] = recordBytes
+	}
+}
+
+func TestMigrationArguments(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	downgradeToLegacySchema(ledger)
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"not an admin", bob, "Migrate", []string{"1", "2", "[]"}, ErrCodeUnauthorized},
+		{"same version", admin, "Migrate", []string{"2", "2", "[]"}, ErrCodeInvalidArgument},
+		{"unknown version", admin, "Migrate", []string{"1", "3", "[]"}, ErrCodeInvalidArgument},
+		{"not an nft or approval key", admin, "Migrate", []string{"1", "2", `["\u0000balance\u0000a\u00001\u0000"]`}, ErrCodeInvalidArgument},
+		{"simple key", admin, "Migrate", []string{"1", "2", `["ERC721Metadata"]`}, ErrCodeInvalidArgument},
+		{"non-positive page size", admin, "PendingMigration", []string{"1", "0", ""}, ErrCodeInvalidArgument},
+		{"malformed bookmark", admin, "PendingMigration", []string{"1", "2", "nft"}, ErrCodeInvalidArgument},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int
+		couchDB  bool
+		pages    int
+	}{
+		{"single page", 10, false, 1},
+		{"page per object type", 4, false, 2},
+		{"pages across object types", 3, false, 2},
+		{"record per page", 1, false, 5},
+		// CouchDB hands out a bookmark with the last page of every object type
+		{"couchdb single page", 10, true, 1},
+		{"couchdb page per object type", 4, true, 2},
+		{"couchdb record per page", 1, true, 6},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ledger, admin := newInitializedLedger(t)
+			ledger.stub.couchDB = test.couchDB
+			bob := newTestClient(t, "Org2MSP", "bob")
+			adminID := ledger.account(admin)
+
+			for tokenId := 1; tokenId <= 4; tokenId++ {
+				ledger.ok(admin, "MintWithTokenURI", strconv.Itoa(tokenId), "ipfs://deed")
+			}
+			ledger.ok(admin, "SetApprovalForAll", ledger.account(bob), "true")
+			downgradeToLegacySchema(ledger)
+			ledger.expect(admin, "1", "SchemaVersion")
+
+			// Collect every page before migrating, each page resumes after the previous one
+			keys := map[string]bool{}
+			pages := 0
+			bookmark := ""
+			for {
+				page := &model.MigrationPage{}
+				err := json.Unmarshal([]byte(ledger.ok(admin, "PendingMigration", "1", strconv.Itoa(test.pageSize), bookmark)), page)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages++
+
+				for _, key := range page.Keys {
+					if keys[key] {
+						t.Fatalf("page %d repeats key %q", pages, key)
+					}
+					keys[key] = true
+				}
+
+				if page.Bookmark == "" {
+					break
+				}
+				if pages > 10 {
+					t.Fatalf("page %d still has bookmark %s", pages, page.Bookmark)
+				}
+				bookmark = page.Bookmark
+			}
+			if pages != test.pages || len(keys) != 5 {
+				t.Fatalf("%d pending keys in %d pages, want 5 in %d", len(keys), pages, test.pages)
+			}
+
+			ledger.fail(admin, "CompleteMigration", string(ErrCodeConflict), "1", "2")
+
+			pending := []string{}
+			for key := range keys {
+				pending = append(pending, key)
+			}
+			pendingBytes, err := json.Marshal(pending)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			migration := &model.Migration{}
+			if err := json.Unmarshal([]byte(ledger.ok(admin, "Migrate", "1", "2", string(pendingBytes))), migration); err != nil {
+				t.Fatal(err)
+			}
+			if migration.Scanned != 5 || migration.Migrated != 5 {
+				t.Fatalf("%+v, want 5 records scanned and migrated", migration)
+			}
+
+			// Migrating the same keys again is a no-op
+			if err := json.Unmarshal([]byte(ledger.ok(admin, "Migrate", "1", "2", string(pendingBytes))), migration); err != nil {
+				t.Fatal(err)
+			}
+			if migration.Migrated != 0 {
+				t.Fatalf("%+v, want no record migrated twice", migration)
+			}
+
+			ledger.expect(admin, `{"keys":[],"bookmark":""}`, "PendingMigration", "1", "10", "")
+			ledger.expect(admin, `{"fromVersion":1,"toVersion":2,"scanned":5,"migrated":0,"done":true}`, "CompleteMigration", "1", "2")
+			ledger.expect(admin, "2", "SchemaVersion")
+			ledger.fail(admin, "CompleteMigration", string(ErrCodeConflict), "1", "2")
+			ledger.expect(admin, adminID, "OwnerOf", "1")
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetMintProposalPolicy` is invoke fnc that sets how many organizations must approve a mint proposal
+and how many seconds a proposal stays open
+*/
+func (c *TokenERC721Contract) SetMintProposalPolicy(ctx contractapi.TransactionContextInterface, quorum int, ttlSeconds int64) (bool, error) {
+
+	if quorum < 1 {
+		return false, invalidArgumentError("quorum must be a positive number")
+	}
+	if ttlSeconds < 1 {
+		return false, invalidArgumentError("ttlSeconds must be a positive number")
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.MintQuorum = quorum
+	metadata.MintProposalTTL = ttlSeconds
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`ProposeMint` is invoke fnc that proposes minting a new non-fungible token to an account.
+The proposal is identified by the transaction ID and counts as the approval of the proposer's organization,
+so only admins of an organization can propose.
+*/
+func (c *TokenERC721Contract) ProposeMint(ctx contractapi.TransactionContextInterface, tokenId string, tokenURI string, to string) (*model.MintProposal, error) {
+
+	if tokenId == "" {
+		return nil, invalidArgumentError("tokenId must not be empty")
+	}
+
+	err := _validateRecipient(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := _repository(ctx).NFTExists(LegacyCollectionID, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, newContractError(ErrCodeAlreadyMinted, "the token %s is already minted", tokenId)
+	}
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal := model.NewMintProposal(ctx.GetStub().GetTxID(), tokenId, tokenURI, to)
+	proposal.Proposer = client.ID
+	proposal.ProposerMSP = clientMSPID
+	proposal.Approvals = append(proposal.Approvals, clientMSPID)
+	proposal.CreatedAt = now
+	proposal.ExpiresAt = now + metadata.StoredMintProposalTTL()
+
+	err = _putMintProposal(ctx, proposal, MintProposedEventKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+/*
+`ApproveMintProposal` is invoke fnc that adds the approval of the requesting client's organization to a pending mint proposal.
+Only admins of an organization can approve for it, and every organization approves a proposal at most once.
+*/
+func (c *TokenERC721Contract) ApproveMintProposal(ctx contractapi.TransactionContextInterface, proposalId string) (*model.MintProposal, error) {
+
+	proposal, err := _readPendingMintProposal(ctx, proposalId)
+	if err != nil {
+		return nil, err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+	if proposal.IsApprovedBy(clientMSPID) {
+		return nil, conflictError("%s already approved mint proposal %s", clientMSPID, proposalId)
+	}
+
+	proposal.Approvals = append(proposal.Approvals, clientMSPID)
+
+	err = _putMintProposal(ctx, proposal, MintProposalApprovedEventKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+/*
+`ExecuteMintProposal` is invoke fnc that mints the token of a pending mint proposal once enough organizations approved it.
+It can be called by a client of any organization that approved the proposal.
+*/
+func (c *TokenERC721Contract) ExecuteMintProposal(ctx contractapi.TransactionContextInterface, proposalId string) (*model.NFT, error) {
+
+	nft := new(model.NFT)
+	request, replayed, err := _replayRequest(ctx, nft)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return nft, nil
+	}
+
+	proposal, err := _readPendingMintProposal(ctx, proposalId)
+	if err != nil {
+		return nil, err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+	if !proposal.IsApprovedBy(clientMSPID) {
+		return nil, unauthorizedError("%s has not approved mint proposal %s", clientMSPID, proposalId)
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(proposal.Approvals) < metadata.StoredMintQuorum() {
+		return nil, conflictError("mint proposal %s has %d of %d required approvals", proposalId, len(proposal.Approvals), metadata.StoredMintQuorum())
+	}
+
+	nft, err = _mint(ctx, LegacyCollectionID, proposal.TokenId, proposal.TokenURI, "", "", proposal.To)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal.Status = model.MintProposalExecuted
+
+	err = _storeMintProposal(ctx, proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit a single event for the executed proposal and the Transfer of the mint
+	transferEvent := model.NewTransferMetadata(ZeroAddress, nft.Owner, nft.TokenId)
+	transferEvent.CollectionId = nft.CollectionId
+
+	executedEventBytes, err := json.Marshal(model.NewMintProposalExecutedEvent(proposal, transferEvent))
+	if err != nil {
+		return nil, internalError("failed to marshal executedEventBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(MintProposalExecutedEventKey, executedEventBytes)
+	if err != nil {
+		return nil, internalError("failed to SetEvent executedEventBytes %s: %v", executedEventBytes, err)
+	}
+
+	err = _completeRequest(ctx, request, nft)
+	if err != nil {
+		return nil, err
+	}
+
+	return nft, nil
+}
+
+/*
+`RejectMintProposal` is invoke fnc that closes a pending mint proposal without minting.
+Only the proposer's organization and the admin organization can reject a proposal.
+*/
+func (c *TokenERC721Contract) RejectMintProposal(ctx contractapi.TransactionContextInterface, proposalId string) (*model.MintProposal, error) {
+
+	proposal, err := _readMintProposal(ctx, proposalId)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != model.MintProposalPending {
+		return nil, conflictError("mint proposal %s is %s", proposalId, proposal.Status)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+	if clientMSPID != proposal.ProposerMSP && clientMSPID != AdminMSPID {
+		return nil, unauthorizedError("client of %s is not authorized to reject mint proposal %s", clientMSPID, proposalId)
+	}
+
+	proposal.Status = model.MintProposalRejected
+
+	err = _putMintProposal(ctx, proposal, MintProposalRejectedEventKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+/*
+`GetMintProposal` is query fnc that returns a mint proposal, pending proposals past their expiry are reported as expired
+*/
+func (c *TokenERC721Contract) GetMintProposal(ctx contractapi.TransactionContextInterface, proposalId string) (*model.MintProposal, error) {
+
+	proposal, err := _readMintProposal(ctx, proposalId)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.IsExpired(now) {
+		proposal.Status = model.MintProposalExpired
+	}
+
+	return proposal, nil
+}
+
+// _readPendingMintProposal reads a proposal that can still be approved or executed
+func _readPendingMintProposal(ctx contractapi.TransactionContextInterface, proposalId string) (*model.MintProposal, error) {
+	proposal, err := _readMintProposal(ctx, proposalId)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != model.MintProposalPending {
+		return nil, conflictError("mint proposal %s is %s", proposalId, proposal.Status)
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.IsExpired(now) {
+		return nil, conflictError("mint proposal %s expired", proposalId)
+	}
+
+	return proposal, nil
+}
+
+func _readMintProposal(ctx contractapi.TransactionContextInterface, proposalId string) (*model.MintProposal, error) {
+	proposalKey, err := ctx.GetStub().CreateCompositeKey(mintProposalPrefix, []string{proposalId})
+	if err != nil {
+		return nil, internalError("failed to CreateCompositeKey proposalKey: %v", err)
+	}
+
+	proposalBytes, err := _repository(ctx).getState(proposalKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(proposalBytes) == 0 {
+		return nil, proposalNotFoundError(proposalId)
+	}
+
+	proposal := model.NewMintProposal("", "", "", "")
+	err = json.Unmarshal(proposalBytes, proposal)
+	if err != nil {
+		return nil, internalError("failed to Unmarshal proposalBytes: %v", err)
+	}
+
+	return proposal, nil
+}
+
+// _putMintProposal stores a proposal and emits it as the eventKey event
+func _putMintProposal(ctx contractapi.TransactionContextInterface, proposal *model.MintProposal, eventKey string) error {
+	err := _storeMintProposal(ctx, proposal)
+	if err != nil {
+		return err
+	}
+
+	proposalBytes, err := json.Marshal(proposal)
+	if err != nil {
+		return internalError("failed to marshal proposalBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(eventKey, proposalBytes)
+	if err != nil {
+		return internalError("failed to SetEvent proposalBytes %s: %v", proposalBytes, err)
+	}
+
+	return nil
+}
+
+// _storeMintProposal writes a proposal without emitting an event
+func _storeMintProposal(ctx contractapi.TransactionContextInterface, proposal *model.MintProposal) error {
+	proposalKey, err := ctx.GetStub().CreateCompositeKey(mintProposalPrefix, []string{proposal.ProposalId})
+	if err != nil {
+		return internalError("failed to CreateCompositeKey proposalKey: %v", err)
+	}
+
+	proposalBytes, err := json.Marshal(proposal)
+	if err != nil {
+		return internalError("failed to marshal proposalBytes: %v", err)
+	}
+
+	return _repository(ctx).putState(proposalKey, proposalBytes)
+}
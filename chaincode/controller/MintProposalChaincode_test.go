@@ -0,0 +1,148 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+// proposeMint submits a mint proposal that must succeed and returns it
+func proposeMint(ledger *testLedger, client testClient, tokenId string, to string) *model.MintProposal {
+	ledger.t.Helper()
+
+	proposal := &model.MintProposal{}
+	if err := json.Unmarshal([]byte(ledger.ok(client, "ProposeMint", tokenId, "ipfs://deed/"+tokenId, to)), proposal); err != nil {
+		ledger.t.Fatal(err)
+	}
+
+	return proposal
+}
+
+func TestProposeMint(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	org2Admin := newOrgAdmin(t, "Org2MSP", "org2admin")
+	org2Member := newTestClient(t, "Org2MSP", "bob")
+	bobID := ledger.account(org2Member)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+
+	tests := []struct {
+		name    string
+		client  testClient
+		tokenId string
+		to      string
+		want    ErrorCode
+	}{
+		{"not an org admin", org2Member, "2", bobID, ErrCodeUnauthorized},
+		{"empty token id", org2Admin, "", bobID, ErrCodeInvalidArgument},
+		{"zero address", org2Admin, "2", ZeroAddress, ErrCodeInvalidArgument},
+		{"already minted", org2Admin, "1", bobID, ErrCodeAlreadyMinted},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "ProposeMint", string(test.want), test.tokenId, "ipfs://deed/2", test.to)
+		})
+	}
+
+	ledger.now = 1000
+	proposal := proposeMint(ledger, org2Admin, "2", bobID)
+
+	if ledger.event().EventName != MintProposedEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, MintProposedEventKey)
+	}
+	if proposal.Status != model.MintProposalPending || proposal.ProposerMSP != "Org2MSP" || len(proposal.Approvals) != 1 {
+		t.Fatalf("proposal %+v", proposal)
+	}
+	if proposal.CreatedAt != 1000 || proposal.ExpiresAt != 1000+7*24*60*60 {
+		t.Fatalf("proposal open from %d to %d", proposal.CreatedAt, proposal.ExpiresAt)
+	}
+}
+
+func TestApproveMintProposal(t *testing.T) {
+	ledger, _ := newInitializedLedger(t)
+	org1Admin := newOrgAdmin(t, AdminMSPID, "org1admin")
+	org2Admin := newOrgAdmin(t, "Org2MSP", "org2admin")
+	org3Admin := newOrgAdmin(t, "Org3MSP", "org3admin")
+	org3Member := newTestClient(t, "Org3MSP", "carol")
+	bobID := ledger.account(newTestClient(t, "Org2MSP", "bob"))
+
+	ledger.now = 1000
+	proposal := proposeMint(ledger, org2Admin, "2", bobID)
+
+	tests := []struct {
+		name       string
+		client     testClient
+		proposalId string
+		want       ErrorCode
+	}{
+		{"not an org admin", org3Member, proposal.ProposalId, ErrCodeUnauthorized},
+		{"unknown proposal", org3Admin, "tx0", ErrCodeProposalNotFound},
+		{"proposer organization", org2Admin, proposal.ProposalId, ErrCodeConflict},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "ApproveMintProposal", string(test.want), test.proposalId)
+		})
+	}
+
+	ledger.ok(org3Admin, "ApproveMintProposal", proposal.ProposalId)
+	ledger.fail(org3Admin, "ApproveMintProposal", string(ErrCodeConflict), proposal.ProposalId)
+
+	ledger.now = proposal.ExpiresAt
+	ledger.fail(org1Admin, "ApproveMintProposal", "expired", proposal.ProposalId)
+
+	approved := &model.MintProposal{}
+	if err := json.Unmarshal([]byte(ledger.ok(org1Admin, "GetMintProposal", proposal.ProposalId)), approved); err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != model.MintProposalExpired || len(approved.Approvals) != 2 {
+		t.Fatalf("proposal %+v", approved)
+	}
+}
+
+func TestExecuteMintProposal(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	org2Admin := newOrgAdmin(t, "Org2MSP", "org2admin")
+	org3Admin := newOrgAdmin(t, "Org3MSP", "org3admin")
+	org2Member := newTestClient(t, "Org2MSP", "bob")
+	bobID := ledger.account(org2Member)
+
+	proposal := proposeMint(ledger, org2Admin, "2", bobID)
+
+	ledger.fail(org2Admin, "ExecuteMintProposal", string(ErrCodeConflict), proposal.ProposalId)
+	ledger.ok(org3Admin, "ApproveMintProposal", proposal.ProposalId)
+	ledger.fail(admin, "ExecuteMintProposal", string(ErrCodeUnauthorized), proposal.ProposalId)
+
+	// Any client of an approving organization can execute
+	ledger.ok(org2Member, "ExecuteMintProposal", proposal.ProposalId)
+
+	// Fabric keeps the last event of the transaction, which carries the Transfer of the mint
+	last := ledger.events[len(ledger.events)-1]
+	if last.EventName != MintProposalExecutedEventKey {
+		t.Fatalf("event %s, want %s", last.EventName, MintProposalExecutedEventKey)
+	}
+	executedEvent := &model.MintProposalExecutedEvent{}
+	if err := json.Unmarshal(last.Payload, executedEvent); err != nil {
+		t.Fatal(err)
+	}
+	if *executedEvent.Transfer != *model.NewTransferMetadata(ZeroAddress, bobID, "2") {
+		t.Fatalf("transfer %+v", executedEvent.Transfer)
+	}
+	if executedEvent.Proposal.ProposalId != proposal.ProposalId || executedEvent.Proposal.Status != model.MintProposalExecuted {
+		t.Fatalf("proposal %+v", executedEvent.Proposal)
+	}
+	ledger.expect(admin, bobID, "OwnerOf", "2")
+	ledger.fail(org2Admin, "ExecuteMintProposal", "executed", proposal.ProposalId)
+
+	rejected := proposeMint(ledger, org2Admin, "3", bobID)
+	ledger.fail(org3Admin, "RejectMintProposal", string(ErrCodeUnauthorized), rejected.ProposalId)
+	ledger.ok(admin, "RejectMintProposal", rejected.ProposalId)
+	ledger.fail(org3Admin, "ApproveMintProposal", "rejected", rejected.ProposalId)
+
+	ledger.ok(admin, "SetMintProposalPolicy", "1", "60")
+	single := proposeMint(ledger, org2Admin, "4", bobID)
+	ledger.ok(org2Admin, "ExecuteMintProposal", single.ProposalId)
+	ledger.expect(admin, bobID, "OwnerOf", "4")
+}
@@ -0,0 +1,229 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetOperatorApproval` is invoke fnc that grants an operator a bounded approval over the message sender's assets.
+expiresAt is the Unix time in seconds from which the grant no longer applies, 0 for never;
+tokenIds limits the grant to these tokens, empty for every token;
+maxTransfers limits how many transfers the grant authorizes, 0 for no limit.
+A grant only ever covers the collection it is set on. It replaces any previous grant to the operator.
+*/
+func (c *TokenERC721Contract) SetOperatorApproval(ctx contractapi.TransactionContextInterface, operator string, expiresAt int64, tokenIds []string, maxTransfers int) (bool, error) {
+	return c.setOperatorApproval(ctx, LegacyCollectionID, operator, expiresAt, tokenIds, maxTransfers)
+}
+
+/*
+`GetOperatorApproval` is query fnc that returns the full grant of owner to operator, not approved when there is none
+*/
+func (c *TokenERC721Contract) GetOperatorApproval(ctx contractapi.TransactionContextInterface, owner string, operator string) (*model.Approval, error) {
+	return c.getOperatorApproval(ctx, LegacyCollectionID, owner, operator)
+}
+
+/*
+`GetOperatorsOf` is query fnc that lists the operator grants of owner that are in force
+*/
+func (c *TokenERC721Contract) GetOperatorsOf(ctx contractapi.TransactionContextInterface, owner string) ([]*model.Approval, error) {
+	return c.getOperatorsOf(ctx, LegacyCollectionID, owner)
+}
+
+/*
+`GetApprovedTokensOf` is query fnc that lists the tokens of owner that have an approved client
+*/
+func (c *TokenERC721Contract) GetApprovedTokensOf(ctx contractapi.TransactionContextInterface, owner string) ([]*model.NFT, error) {
+	return c.getApprovedTokensOf(ctx, LegacyCollectionID, owner)
+}
+
+/*
+`RevokeAllApprovals` is invoke fnc that removes every operator approval granted by the message sender
+and clears the approved client of every token it owns, in every collection
+*/
+func (c *TokenERC721Contract) RevokeAllApprovals(ctx contractapi.TransactionContextInterface) (*model.Revocation, error) {
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionIds, err := _collectionIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := _repository(ctx)
+	revocation := model.NewRevocation(client.ID)
+
+	for _, collectionId := range append([]string{LegacyCollectionID}, collectionIds...) {
+		// Tokens minted before canonical account IDs are still held by the legacy ID
+		for _, owner := range []string{client.ID, client.LegacyID} {
+			approvals, err := repository.ApprovalsOf(collectionId, owner)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, approval := range approvals {
+				err = repository.DeleteApproval(collectionId, owner, approval.Operator)
+				if err != nil {
+					return nil, err
+				}
+				revocation.Operators++
+			}
+
+			nfts, err := c.getApprovedTokensOf(ctx, collectionId, owner)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, nft := range nfts {
+				nft.Approved = ""
+
+				err = repository.PutNFT(nft)
+				if err != nil {
+					return nil, err
+				}
+				revocation.Tokens++
+			}
+		}
+	}
+
+	revocationBytes, err := json.Marshal(revocation)
+	if err != nil {
+		return nil, internalError("failed to marshal revocationBytes: %v", err)
+	}
+
+	err = ctx.GetStub().SetEvent(ApprovalsRevokedEventKey, revocationBytes)
+	if err != nil {
+		return nil, internalError("failed to SetEvent revocationBytes %s: %v", revocationBytes, err)
+	}
+
+	return revocation, nil
+}
+
+func (c *TokenERC721Contract) setOperatorApproval(ctx contractapi.TransactionContextInterface, collectionId string, operator string, expiresAt int64, tokenIds []string, maxTransfers int) (bool, error) {
+
+	if operator == "" {
+		return false, invalidArgumentError("operator must not be empty")
+	}
+	if maxTransfers < 0 {
+		return false, invalidArgumentError("maxTransfers must not be negative")
+	}
+	for _, tokenId := range tokenIds {
+		if tokenId == "" {
+			return false, invalidArgumentError("tokenIds must not contain an empty tokenId")
+		}
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return false, err
+	}
+	if expiresAt != 0 && expiresAt <= now {
+		return false, invalidArgumentError("expiresAt %d is not in the future", expiresAt)
+	}
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	nftApproval := model.NewApproval(client.ID, operator, true)
+	nftApproval.CollectionId = collectionId
+	nftApproval.Version = model.CurrentSchemaVersion
+	nftApproval.ExpiresAt = expiresAt
+	nftApproval.TokenIds = tokenIds
+	nftApproval.MaxTransfers = maxTransfers
+
+	err = _putOperatorApproval(ctx, client, nftApproval)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) getOperatorApproval(ctx contractapi.TransactionContextInterface, collectionId string, owner string, operator string) (*model.Approval, error) {
+
+	approval, err := _repository(ctx).GetApproval(collectionId, owner, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval == nil {
+		approval = model.NewApproval(owner, operator, false)
+		approval.CollectionId = collectionId
+		approval.Version = model.CurrentSchemaVersion
+	}
+
+	return approval, nil
+}
+
+func (c *TokenERC721Contract) getOperatorsOf(ctx contractapi.TransactionContextInterface, collectionId string, owner string) ([]*model.Approval, error) {
+
+	approvals, err := _repository(ctx).ApprovalsOf(collectionId, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	operators := []*model.Approval{}
+	for _, approval := range approvals {
+		if approval.IsActive(now) {
+			operators = append(operators, approval)
+		}
+	}
+
+	return operators, nil
+}
+
+func (c *TokenERC721Contract) getApprovedTokensOf(ctx contractapi.TransactionContextInterface, collectionId string, owner string) ([]*model.NFT, error) {
+
+	repository := _repository(ctx)
+
+	tokenIds, err := repository.TokenIdsOf(collectionId, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts := []*model.NFT{}
+	for _, tokenId := range tokenIds {
+		nft, err := repository.GetNFT(collectionId, tokenId)
+		if err != nil {
+			return nil, err
+		}
+		if nft.Approved != "" {
+			nfts = append(nfts, nft)
+		}
+	}
+
+	return nfts, nil
+}
+
+// _collectionIds lists the collections created with `CreateCollection`
+func _collectionIds(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	collectionIds := []string{}
+
+	err := _repository(ctx).scanObjectType(collectionPrefix, func(key string, value []byte) error {
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(key)
+		if err != nil {
+			return internalError("failed to SplitCompositeKey %s: %v", key, err)
+		}
+
+		collectionIds = append(collectionIds, attributes[0])
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return collectionIds, nil
+}
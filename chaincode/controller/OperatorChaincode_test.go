@@ -0,0 +1,284 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+// operatorApproval reads the operator approval granted by owner
+func operatorApproval(ledger *testLedger, client testClient, owner string, operator string) *model.Approval {
+	ledger.t.Helper()
+
+	approval := &model.Approval{}
+	if err := json.Unmarshal([]byte(ledger.ok(client, "GetOperatorApproval", owner, operator)), approval); err != nil {
+		ledger.t.Fatal(err)
+	}
+
+	return approval
+}
+
+func TestSetOperatorApproval(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	operatorID := ledger.account(newTestClient(t, "Org2MSP", "operator"))
+	ledger.now = 1000
+
+	tests := []struct {
+		name         string
+		operator     string
+		expiresAt    string
+		tokenIds     string
+		maxTransfers string
+		want         ErrorCode
+	}{
+		{"empty operator", "", "0", "[]", "0", ErrCodeInvalidArgument},
+		{"negative max transfers", operatorID, "0", "[]", "-1", ErrCodeInvalidArgument},
+		{"empty token id", operatorID, "0", `["1",""]`, "0", ErrCodeInvalidArgument},
+		{"expired", operatorID, "1000", "[]", "0", ErrCodeInvalidArgument},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(admin, "SetOperatorApproval", string(test.want), test.operator, test.expiresAt, test.tokenIds, test.maxTransfers)
+		})
+	}
+
+	ledger.ok(admin, "SetOperatorApproval", operatorID, "2000", `["1","2"]`, "3")
+
+	approval := operatorApproval(ledger, admin, ledger.account(admin), operatorID)
+	if !approval.Approved || approval.ExpiresAt != 2000 || len(approval.TokenIds) != 2 || approval.MaxTransfers != 3 || approval.Transfers != 0 {
+		t.Fatalf("approval %+v", approval)
+	}
+}
+
+func TestScopedOperatorApproval(t *testing.T) {
+	for _, encoding := range []string{model.JSONEncoding, model.BinaryEncoding} {
+		t.Run(encoding, func(t *testing.T) {
+			ledger, admin := newInitializedLedger(t)
+			operator := newTestClient(t, "Org2MSP", "operator")
+			bob := newTestClient(t, "Org2MSP", "bob")
+			adminID, operatorID, bobID := ledger.account(admin), ledger.account(operator), ledger.account(bob)
+
+			ledger.ok(admin, "SetStateEncoding", encoding)
+			for tokenId := 1; tokenId <= 5; tokenId++ {
+				ledger.ok(admin, "MintWithTokenURI", fmt.Sprint(tokenId), "ipfs://deed/"+fmt.Sprint(tokenId))
+			}
+
+			ledger.now = 1000
+			ledger.ok(admin, "SetOperatorApproval", operatorID, "0", `["1","2","3"]`, "2")
+
+			tests := []struct {
+				name     string
+				function string
+				args     []string
+				want     ErrorCode
+			}{
+				{"token out of scope", "TransferFrom", []string{adminID, bobID, "4"}, ErrCodeUnauthorized},
+				{"approve with limited transfers", "Approve", []string{operatorID, "1"}, ErrCodeUnauthorized},
+			}
+
+			for _, test := range tests {
+				ledger.run(test.name, func(t *testing.T) {
+					ledger.fail(operator, test.function, string(test.want), test.args...)
+				})
+			}
+
+			// Scoped grants are not approvals for all
+			ledger.expect(admin, "false", "IsApprovedForAll", adminID, operatorID)
+
+			ledger.ok(operator, "TransferFrom", adminID, bobID, "1")
+			ledger.ok(operator, "TransferFrom", adminID, bobID, "2")
+			if approval := operatorApproval(ledger, admin, adminID, operatorID); approval.Transfers != 2 {
+				t.Fatalf("approval used %d transfers, want 2", approval.Transfers)
+			}
+			ledger.fail(operator, "TransferFrom", string(ErrCodeUnauthorized), adminID, bobID, "3")
+
+			// An unscoped grant expires with the transaction timestamp
+			ledger.ok(admin, "SetOperatorApproval", operatorID, "2000", "[]", "0")
+			ledger.expect(admin, "true", "IsApprovedForAll", adminID, operatorID)
+			ledger.ok(operator, "Approve", bobID, "3")
+			ledger.ok(bob, "TransferFrom", adminID, bobID, "3")
+
+			ledger.now = 2000
+			ledger.expect(admin, "false", "IsApprovedForAll", adminID, operatorID)
+			ledger.fail(operator, "TransferFrom", string(ErrCodeUnauthorized), adminID, bobID, "4")
+
+			// SetApprovalForAll replaces the limited grant with an unlimited one
+			ledger.ok(admin, "SetApprovalForAll", operatorID, "true")
+			if approval := operatorApproval(ledger, admin, adminID, operatorID); approval.ExpiresAt != 0 || approval.IsScoped() {
+				t.Fatalf("approval %+v", approval)
+			}
+			ledger.ok(operator, "TransferFrom", adminID, bobID, "4")
+			ledger.expect(admin, "4", "BalanceOf", bobID)
+		})
+	}
+}
+
+func TestCollectionOperatorApproval(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	operator := newTestClient(t, "Org2MSP", "operator")
+	adminID, operatorID := ledger.account(admin), ledger.account(operator)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "1", "")
+	ledger.ok(admin, "CollectionSetOperatorApproval", "deeds", operatorID, "0", `["1"]`, "1")
+
+	// The grant only applies in the collection it was set in
+	ledger.fail(operator, "TransferFrom", string(ErrCodeUnauthorized), adminID, operatorID, "1")
+	if operatorApproval(ledger, admin, adminID, operatorID).Approved {
+		t.Fatal("the collection grant applies to the legacy collection")
+	}
+	ledger.ok(operator, "CollectionTransferFrom", "deeds", adminID, operatorID, "1")
+
+	approval := &model.Approval{}
+	if err := json.Unmarshal([]byte(ledger.ok(admin, "CollectionGetOperatorApproval", "deeds", adminID, operatorID)), approval); err != nil {
+		t.Fatal(err)
+	}
+	if approval.CollectionId != "deeds" || approval.Transfers != 1 || approval.IsActive(0) {
+		t.Fatalf("approval %+v", approval)
+	}
+}
+
+// listed decodes a JSON list and returns the value of field of every entry
+func listed(ledger *testLedger, listJSON string, field string) []string {
+	ledger.t.Helper()
+
+	entries := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(listJSON), &entries); err != nil {
+		ledger.t.Fatal(err)
+	}
+
+	values := []string{}
+	for _, entry := range entries {
+		values = append(values, fmt.Sprint(entry[field]))
+	}
+
+	return values
+}
+
+func TestListApprovals(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+	carolID, daveID := ledger.account(newTestClient(t, "Org2MSP", "carol")), ledger.account(newTestClient(t, "Org2MSP", "dave"))
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	for _, tokenId := range []string{"1", "2", "3"} {
+		ledger.ok(admin, "MintWithTokenURI", tokenId, "ipfs://deed/"+tokenId)
+	}
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "1", "")
+
+	ledger.now = 1000
+	ledger.ok(admin, "SetApprovalForAll", bobID, "true")
+	ledger.ok(admin, "SetOperatorApproval", carolID, "2000", "[]", "0")
+	ledger.ok(admin, "SetOperatorApproval", daveID, "0", `["3"]`, "1")
+	ledger.ok(admin, "CollectionSetApprovalForAll", "deeds", daveID, "true")
+	ledger.ok(admin, "Approve", carolID, "1")
+	ledger.ok(admin, "Approve", daveID, "2")
+	ledger.ok(admin, "CollectionApprove", "deeds", bobID, "1")
+
+	// Dave uses up the single transfer of the grant
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "3")
+	ledger.ok(bob, "SetApprovalForAll", adminID, "true")
+	ledger.ok(admin, "TransferFrom", bobID, adminID, "3")
+
+	tests := []struct {
+		name     string
+		now      int64
+		function string
+		args     []string
+		field    string
+		want     []string
+	}{
+		{"operators", 1000, "GetOperatorsOf", []string{adminID}, "operator", []string{bobID, carolID, daveID}},
+		{"operators after expiry", 2000, "GetOperatorsOf", []string{adminID}, "operator", []string{bobID, daveID}},
+		{"collection operators", 1000, "CollectionGetOperatorsOf", []string{"deeds", adminID}, "operator", []string{daveID}},
+		{"approved tokens", 1000, "GetApprovedTokensOf", []string{adminID}, "tokenId", []string{"1", "2"}},
+		{"collection approved tokens", 1000, "CollectionGetApprovedTokensOf", []string{"deeds", adminID}, "tokenId", []string{"1"}},
+		{"no approvals", 1000, "GetApprovedTokensOf", []string{bobID}, "tokenId", []string{}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.now = test.now
+			got := listed(ledger, ledger.ok(bob, test.function, test.args...), test.field)
+
+			want := map[string]bool{}
+			for _, value := range test.want {
+				want[value] = true
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%s = %q, want %q", test.function, got, test.want)
+			}
+			for _, value := range got {
+				if !want[value] {
+					t.Fatalf("%s = %q, want %q", test.function, got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRevokeAllApprovals(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	carol := newTestClient(t, "Org2MSP", "carol")
+	adminID, bobID, carolID := ledger.account(admin), ledger.account(bob), ledger.account(carol)
+	bobLegacyID := "x509::CN=bob::CN=bob"
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	for _, tokenId := range []string{"1", "2", "3"} {
+		ledger.ok(admin, "MintWithTokenURI", tokenId, "ipfs://deed/"+tokenId)
+	}
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "1", "")
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "1")
+	ledger.ok(admin, "TransferFrom", adminID, bobLegacyID, "2")
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "3")
+	ledger.ok(admin, "CollectionTransferFrom", "deeds", adminID, bobID, "1")
+
+	ledger.ok(bob, "SetApprovalForAll", carolID, "true")
+	ledger.ok(bob, "CollectionSetApprovalForAll", "deeds", carolID, "true")
+	ledger.ok(bob, "Approve", adminID, "1")
+	ledger.ok(bob, "Approve", adminID, "2")
+	ledger.ok(bob, "CollectionApprove", "deeds", adminID, "1")
+
+	// Grants stored under the legacy ID are revoked with the canonical ones
+	ledger.seed(approvalPrefix, []string{bobLegacyID, adminID}, model.NewApproval(bobLegacyID, adminID, true))
+
+	revocation := &model.Revocation{}
+	if err := json.Unmarshal([]byte(ledger.ok(bob, "RevokeAllApprovals")), revocation); err != nil {
+		t.Fatal(err)
+	}
+	if *revocation != (model.Revocation{Owner: bobID, Operators: 3, Tokens: 3}) {
+		t.Fatalf("revocation %+v", revocation)
+	}
+	if ledger.event().EventName != ApprovalsRevokedEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, ApprovalsRevokedEventKey)
+	}
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+	}{
+		{"operator", carol, "TransferFrom", []string{bobID, carolID, "3"}},
+		{"collection operator", carol, "CollectionTransferFrom", []string{"deeds", bobID, carolID, "1"}},
+		{"approved client", admin, "TransferFrom", []string{bobID, adminID, "1"}},
+		{"legacy operator", admin, "TransferFrom", []string{bobLegacyID, adminID, "2"}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(ErrCodeUnauthorized), test.args...)
+		})
+	}
+
+	ledger.expect(bob, "[]", "GetOperatorsOf", bobID)
+	ledger.expect(bob, "[]", "CollectionGetApprovedTokensOf", "deeds", bobID)
+
+	// Nothing is left to revoke
+	ledger.expect(bob, `{"owner":"`+bobID+`","operators":0,"tokens":0}`, "RevokeAllApprovals")
+}
@@ -0,0 +1,64 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`Pause` is invoke fnc that stops every transfer, mint, burn and approval until `Unpause`
+*/
+func (c *TokenERC721Contract) Pause(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return _setPaused(ctx, true)
+}
+
+/*
+`Unpause` is invoke fnc that resumes the transactions stopped by `Pause`
+*/
+func (c *TokenERC721Contract) Unpause(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return _setPaused(ctx, false)
+}
+
+/*
+`Paused` is query fnc that reports whether the contract is paused
+*/
+func (c *TokenERC721Contract) Paused(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return _isPaused(ctx)
+}
+
+func _setPaused(ctx contractapi.TransactionContextInterface, paused bool) (bool, error) {
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.Paused = paused
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// _isPaused reads the pause flag of the contract, a contract that is not initialized is never paused
+func _isPaused(ctx contractapi.TransactionContextInterface) (bool, error) {
+	metadataBytes, err := _repository(ctx).getState(InitialKey)
+	if err != nil {
+		return false, err
+	}
+	if metadataBytes == nil {
+		return false, nil
+	}
+
+	metadata := model.NewERC721Metadata("", "")
+	err = json.Unmarshal(metadataBytes, metadata)
+	if err != nil {
+		return false, internalError("failed unmarshal")
+	}
+
+	return metadata.Paused, nil
+}
@@ -0,0 +1,50 @@
+package chaincode
+
+import "testing"
+
+func TestPause(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+
+	ledger.fail(bob, "Pause", string(ErrCodeUnauthorized))
+	ledger.expect(bob, "false", "Paused")
+	ledger.ok(admin, "Pause")
+	ledger.expect(bob, "true", "Paused")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     string
+	}{
+		{"mint", admin, "MintWithTokenURI", []string{"3", "ipfs://deed/3"}, string(ErrCodePaused)},
+		{"transfer", admin, "TransferFrom", []string{adminID, bobID, "1"}, string(ErrCodePaused)},
+		{"approve", admin, "Approve", []string{bobID, "1"}, string(ErrCodePaused)},
+		{"approve for all", admin, "SetApprovalForAll", []string{bobID, "true"}, string(ErrCodePaused)},
+		{"burn", admin, "Burn", []string{"2"}, string(ErrCodePaused)},
+		{"fractionalize", admin, "Fractionalize", []string{"2", "10"}, string(ErrCodePaused)},
+		{"owner query", bob, "OwnerOf", []string{"1"}, ""},
+		{"balance query", bob, "BalanceOf", []string{adminID}, ""},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			if test.want == "" {
+				ledger.ok(test.client, test.function, test.args...)
+				return
+			}
+			ledger.fail(test.client, test.function, test.want, test.args...)
+		})
+	}
+
+	ledger.fail(bob, "Unpause", string(ErrCodeUnauthorized))
+	ledger.ok(admin, "Unpause")
+	ledger.expect(bob, "false", "Paused")
+	ledger.ok(admin, "TransferFrom", adminID, bobID, "1")
+	ledger.expect(bob, bobID, "OwnerOf", "1")
+}
@@ -1,144 +1,96 @@
 package chaincode
 
 import (
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
 	"hyperledger_erc721/chaincode/model"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
-func _readNFT(ctx contractapi.TransactionContextInterface, tokenId string) (*model.NFT, error) {
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to CreateCompositeKey %s: %v", tokenId, err)
-	}
+/*
+`BalanceOf` is query fnc that counts all non-fungible tokens assigned to an owner
+*/
+func (c *TokenERC721Contract) BalanceOf(ctx contractapi.TransactionContextInterface, owner string) (int, error) {
+	return c.CollectionBalanceOf(ctx, LegacyCollectionID, owner)
+}
 
-	nftBytes, err := ctx.GetStub().GetState(nftKey)
+func (c *TokenERC721Contract) OwnerOf(ctx contractapi.TransactionContextInterface, tokenId string) (string, error) {
+	return c.ownerOf(ctx, LegacyCollectionID, tokenId)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to GetState %s: %v", tokenId, err)
-	}
+func (c *TokenERC721Contract) ownerOf(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
 
-	nft := model.NewNFT("", "", "", "")
-	err = json.Unmarshal(nftBytes, nft)
+	nft, err := _repository(ctx).GetNFT(collectionId, tokenId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to Unmarshal nftBytes: %v", err)
+		return "", err
 	}
 
-	return nft, nil
+	return nft.Owner, nil
 }
 
-func _nftExists(ctx contractapi.TransactionContextInterface, tokenId string) bool {
-	nftKey, err := ctx.GetStub().CreateCompositeKey(nftPrefix, []string{tokenId})
-	if err != nil {
-		panic("error creating CreateCompositeKey:" + err.Error())
-	}
-
-	nftBytes, err := ctx.GetStub().GetState(nftKey)
-	if err != nil {
-		panic("error GetState nftBytes:" + err.Error())
-	}
-
-	return len(nftBytes) > 0
+func (c *TokenERC721Contract) IsApprovedForAll(ctx contractapi.TransactionContextInterface, owner string, operator string) (bool, error) {
+	return c.isApprovedForAll(ctx, LegacyCollectionID, owner, operator)
 }
 
-func (c *TokenERC721Contract) BalanceOf(ctx contractapi.TransactionContextInterface, owner string) int {
+// isApprovedForAll reports whether operator holds an active grant of owner that is not limited to some tokens
+func (c *TokenERC721Contract) isApprovedForAll(ctx contractapi.TransactionContextInterface, collectionId string, owner string, operator string) (bool, error) {
 
-	initialized, err := checkInitialized(ctx)
+	approval, err := _repository(ctx).GetApproval(collectionId, owner, operator)
 	if err != nil {
-		panic(err.Error())
+		return false, err
 	}
-	if !initialized {
-		panic("first initialized")
+	if approval == nil {
+		return false, nil
 	}
 
-	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(balancePrefix, []string{owner})
+	now, err := _txTimestamp(ctx)
 	if err != nil {
-		panic("Error creating asset chaincode:" + err.Error())
+		return false, err
 	}
 
-	balance := 0
-	for iterator.HasNext() {
-		_, err := iterator.Next()
-		if err != nil {
-			return 0
-		}
-		balance++
+	return approval.IsActive(now) && !approval.IsScoped(), nil
 
-	}
-	return balance
 }
 
-func (c *TokenERC721Contract) OwnerOf(ctx contractapi.TransactionContextInterface, tokenId string) (string, error) {
-
-	initialized, err := checkInitialized(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return "", fmt.Errorf("first initialize")
-	}
-
-	nft, err := _readNFT(ctx, tokenId)
+// clientOperatorGrant finds an active operator grant covering tokenId that owner, or the canonical alias of a legacy owner,
+// gave to the client in either account format, nil when there is none
+func (c *TokenERC721Contract) clientOperatorGrant(ctx contractapi.TransactionContextInterface, collectionId string, owner string, client *clientAccount, tokenId string) (*model.Approval, error) {
+	owners, err := _accountAliases(ctx, owner)
 	if err != nil {
-		return "", fmt.Errorf("could not process OwnerOf for tokenId: %w", err)
+		return nil, err
 	}
 
-	return nft.Owner, nil
-}
-
-func (c *TokenERC721Contract) IsApprovedForAll(ctx contractapi.TransactionContextInterface, owner string, operator string) (bool, error) {
-
-	initialized, err := checkInitialized(ctx)
+	now, err := _txTimestamp(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return false, fmt.Errorf("first initialize")
+		return nil, err
 	}
 
-	approvalKey, err := ctx.GetStub().CreateCompositeKey(approvalPrefix, []string{owner, operator})
-	if err != nil {
-		return false, fmt.Errorf("failed to CreateCompositeKey: %v", err)
-	}
-	approvalBytes, err := ctx.GetStub().GetState(approvalKey)
-	if err != nil {
-		return false, fmt.Errorf("failed to GetState approvalBytes %s: %v", approvalBytes, err)
-	}
-
-	if len(approvalBytes) < 1 {
-		return false, nil
-	}
-
-	approval := model.NewApproval("", "", false)
-	err = json.Unmarshal(approvalBytes, approval)
-	if err != nil {
-		return false, fmt.Errorf("failed to Unmarshal: %v, string %s", err, string(approvalBytes))
+	for _, approvalOwner := range owners {
+		for _, operator := range []string{client.ID, client.LegacyID} {
+			approval, err := _repository(ctx).GetApproval(collectionId, approvalOwner, operator)
+			if err != nil {
+				return nil, err
+			}
+			if approval != nil && approval.IsActive(now) && approval.Covers(tokenId) {
+				return approval, nil
+			}
+		}
 	}
 
-	return approval.Approved, nil
-
+	return nil, nil
 }
 
 /*
 `GetApproved` is query fnc that returns the approved client for a single non-fungible token
 */
 func (c *TokenERC721Contract) GetApproved(ctx contractapi.TransactionContextInterface, tokenId string) (string, error) {
+	return c.getApproved(ctx, LegacyCollectionID, tokenId)
+}
 
-	initialized, err := checkInitialized(ctx)
-	if err != nil {
-		return "false", fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return "false", fmt.Errorf("please first initialize")
-	}
+func (c *TokenERC721Contract) getApproved(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
 
-	nft, err := _readNFT(ctx, tokenId)
+	nft, err := _repository(ctx).GetNFT(collectionId, tokenId)
 	if err != nil {
-		return "false", fmt.Errorf("failed GetApproved for tokenId : %v", err)
+		return "false", err
 	}
 
 	return *nft.GetApproved(), nil
@@ -148,51 +100,33 @@ func (c *TokenERC721Contract) GetApproved(ctx contractapi.TransactionContextInte
 `TokenURI` is query fnc that TokenURI returns a distinct Uniform Resource Identifier (URI) for a given token.
 */
 func (c *TokenERC721Contract) TokenURI(ctx contractapi.TransactionContextInterface, tokenId string) (string, error) {
+	return c.tokenURI(ctx, LegacyCollectionID, tokenId)
+}
 
-	initialized, err := checkInitialized(ctx)
+func (c *TokenERC721Contract) tokenURI(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string) (string, error) {
+
+	nft, err := _repository(ctx).GetNFT(collectionId, tokenId)
 	if err != nil {
-		return "", fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return "", fmt.Errorf("please first initialize")
+		return "", err
 	}
 
-	nft, err := _readNFT(ctx, tokenId)
-	if err != nil {
-		return "", fmt.Errorf("failed to get TokenURI: %v", err)
+	// Tokens minted without their own URI fall back to the collection's base URI
+	if nft.TokenURI == "" && collectionId != LegacyCollectionID {
+		metadata, err := _readCollectionMetadata(ctx, collectionId)
+		if err != nil {
+			return "", err
+		}
+		return metadata.BaseURI + tokenId, nil
 	}
+
 	return nft.TokenURI, nil
 }
 
 /*
 `TotalSupply` is query fnc that counts non-fungible tokens tracked by this contract.
 */
-func (c *TokenERC721Contract) TotalSupply(ctx contractapi.TransactionContextInterface) int {
-
-	initialized, err := checkInitialized(ctx)
-	if err != nil {
-		panic("failed to check if contract ia already initialized:" + err.Error())
-	}
-	if !initialized {
-		panic("please first initialize")
-	}
-
-	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(nftPrefix, []string{})
-	if err != nil {
-		panic("Error creating GetStateByPartialCompositeKey:" + err.Error())
-	}
-
-	totalSupply := 0
-	for iterator.HasNext() {
-		_, err := iterator.Next()
-		if err != nil {
-			return 0
-		}
-		totalSupply++
-
-	}
-	return totalSupply
-
+func (c *TokenERC721Contract) TotalSupply(ctx contractapi.TransactionContextInterface) (int, error) {
+	return c.CollectionTotalSupply(ctx, LegacyCollectionID)
 }
 
 /*
@@ -200,25 +134,21 @@ func (c *TokenERC721Contract) TotalSupply(ctx contractapi.TransactionContextInte
 */
 func (c *TokenERC721Contract) ClientAccountBalance(ctx contractapi.TransactionContextInterface) (int, error) {
 
-	initialized, err := checkInitialized(ctx)
+	client, err := _getClientAccount(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to check if contract ia already initialized: %v", err)
-	}
-	if !initialized {
-		return 0, fmt.Errorf("please first initialize")
+		return 0, err
 	}
 
-	clientAccountID64, err := ctx.GetClientIdentity().GetID()
+	balance, err := c.BalanceOf(ctx, client.ID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to GetClientIdentity minter: %v", err)
+		return 0, err
 	}
 
-	clientAccountIDBytes, err := base64.StdEncoding.DecodeString(clientAccountID64)
+	// Tokens minted before canonical account IDs are still held by the legacy ID
+	legacyBalance, err := c.BalanceOf(ctx, client.LegacyID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to DecodeString sender: %v", err)
+		return 0, err
 	}
 
-	clientAccountID := string(clientAccountIDBytes)
-
-	return c.BalanceOf(ctx, clientAccountID), nil
+	return balance + legacyBalance, nil
 }
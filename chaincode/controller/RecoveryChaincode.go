@@ -0,0 +1,361 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetRecoveryQuorum` is invoke fnc that sets how many organizations must approve a forced transfer,
+the organization of the recovery agent included. 1 lets recovery agents act alone.
+*/
+func (c *TokenERC721Contract) SetRecoveryQuorum(ctx contractapi.TransactionContextInterface, quorum int) (bool, error) {
+	return c.setRecoveryQuorum(ctx, LegacyCollectionID, quorum)
+}
+
+/*
+`CollectionSetRecoveryQuorum` is invoke fnc that sets how many organizations must approve a forced transfer in a collection
+*/
+func (c *TokenERC721Contract) CollectionSetRecoveryQuorum(ctx contractapi.TransactionContextInterface, collectionId string, quorum int) (bool, error) {
+	return c.setRecoveryQuorum(ctx, collectionId, quorum)
+}
+
+/*
+`ApproveRecovery` is invoke fnc that adds the approval of the requesting client's organization
+to reassigning a token to an account under a legal case. Every organization approves a case at most once.
+It is restricted to the recovery agents of each organization.
+*/
+func (c *TokenERC721Contract) ApproveRecovery(ctx contractapi.TransactionContextInterface, caseReference string, tokenId string, to string) (*model.RecoveryApproval, error) {
+	return c.approveRecovery(ctx, LegacyCollectionID, caseReference, tokenId, to)
+}
+
+/*
+`ForceTransfer` is invoke fnc that reassigns a token to an account without the consent of its owner, for a court order or a lost key.
+It is restricted to recovery agents and needs the approvals of the recovery quorum. Compliance holds do not apply to it.
+An immutable recovery record is written for caseReference and the ForcedTransfer event is emitted instead of Transfer.
+*/
+func (c *TokenERC721Contract) ForceTransfer(ctx contractapi.TransactionContextInterface, tokenId string, to string, caseReference string) (*model.RecoveryRecord, error) {
+	return c.forceTransfer(ctx, LegacyCollectionID, tokenId, to, caseReference)
+}
+
+/*
+`GetRecoveryRecord` is query fnc that returns the recovery record of a token under a legal case
+*/
+func (c *TokenERC721Contract) GetRecoveryRecord(ctx contractapi.TransactionContextInterface, caseReference string, tokenId string) (*model.RecoveryRecord, error) {
+	return c.getRecoveryRecord(ctx, LegacyCollectionID, caseReference, tokenId)
+}
+
+func (c *TokenERC721Contract) setRecoveryQuorum(ctx contractapi.TransactionContextInterface, collectionId string, quorum int) (bool, error) {
+
+	if quorum < 1 {
+		return false, invalidArgumentError("quorum must be a positive number")
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.RecoveryQuorum = quorum
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) approveRecovery(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string, to string) (*model.RecoveryApproval, error) {
+
+	if caseReference == "" {
+		return nil, invalidArgumentError("caseReference must not be empty")
+	}
+
+	err := _validateRecipient(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := _repository(ctx).NFTExists(collectionId, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, tokenNotFoundError(tokenId)
+	}
+
+	record, err := _readRecoveryRecord(ctx, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		return nil, conflictError("case %s already recovered the token %s", caseReference, tokenId)
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+
+	approval, err := _readRecoveryApproval(ctx, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if approval == nil {
+		approval = model.NewRecoveryApproval(caseReference, collectionId, tokenId, to)
+	}
+	if approval.To != to {
+		return nil, conflictError("case %s recovers the token %s to %s", caseReference, tokenId, approval.To)
+	}
+	if approval.IsApprovedBy(clientMSPID) {
+		return nil, conflictError("%s already approved the recovery of case %s", clientMSPID, caseReference)
+	}
+
+	approval.Approvals = append(approval.Approvals, clientMSPID)
+
+	approvalBytes, err := json.Marshal(approval)
+	if err != nil {
+		return nil, internalError("failed to marshal approvalBytes: %v", err)
+	}
+
+	approvalKey, err := _repository(ctx).key(recoveryApprovalPrefix, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _repository(ctx).putState(approvalKey, approvalBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().SetEvent(RecoveryApprovedEventKey, approvalBytes)
+	if err != nil {
+		return nil, internalError("failed to SetEvent approvalBytes %s: %v", approvalBytes, err)
+	}
+
+	return approval, nil
+}
+
+func (c *TokenERC721Contract) forceTransfer(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, to string, caseReference string) (*model.RecoveryRecord, error) {
+
+	if caseReference == "" {
+		return nil, invalidArgumentError("caseReference must not be empty")
+	}
+
+	err := _validateRecipient(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := _repository(ctx)
+
+	nft, err := repository.GetNFT(collectionId, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if nft.Owner == to {
+		return nil, conflictError("the token %s is already owned by %s", tokenId, to)
+	}
+
+	if collectionId == LegacyCollectionID {
+		fractionalized, err := _isFractionalized(ctx, tokenId)
+		if err != nil {
+			return nil, err
+		}
+		if fractionalized {
+			return nil, conflictError("non-fungible token %s is fractionalized, redeem it first", tokenId)
+		}
+	}
+
+	record, err := _readRecoveryRecord(ctx, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		return nil, conflictError("case %s already recovered the token %s", caseReference, tokenId)
+	}
+
+	agent, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	agentMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, internalError("failed to get clientMSPID: %v", err)
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals, err := _recoveryApprovals(ctx, collectionId, caseReference, tokenId, to, agentMSPID)
+	if err != nil {
+		return nil, err
+	}
+
+	record = &model.RecoveryRecord{
+		CaseReference: caseReference,
+		CollectionId:  collectionId,
+		TokenId:       tokenId,
+		From:          nft.Owner,
+		To:            to,
+		Agent:         agent.ID,
+		AgentMSP:      agentMSPID,
+		Approvals:     approvals,
+		TxId:          ctx.GetStub().GetTxID(),
+		Timestamp:     now,
+	}
+
+	// Clear the approved client and reassign the token
+	nft.Approved = ""
+	nft.Owner = to
+
+	err = repository.PutNFT(nft)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.MoveBalance(collectionId, record.From, to, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := model.NewTransferMetadata(record.From, to, tokenId)
+	transfer.CollectionId = collectionId
+
+	err = _recordActivity(ctx, transfer)
+	if err != nil {
+		return nil, err
+	}
+
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return nil, internalError("failed to marshal recordBytes: %v", err)
+	}
+
+	recordKey, err := repository.key(recoveryPrefix, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.putState(recordKey, recordBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().SetEvent(ForcedTransferEventKey, recordBytes)
+	if err != nil {
+		return nil, internalError("failed to SetEvent recordBytes %s: %v", recordBytes, err)
+	}
+
+	return record, nil
+}
+
+func (c *TokenERC721Contract) getRecoveryRecord(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string) (*model.RecoveryRecord, error) {
+
+	record, err := _readRecoveryRecord(ctx, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, newContractError(ErrCodeRecoveryNotFound, "case %s has no recovery record for the token %s", caseReference, tokenId)
+	}
+
+	return record, nil
+}
+
+// _recoveryApprovals returns the organizations approving a forced transfer, the agent's included,
+// and consumes the stored approvals once they reach the recovery quorum
+func _recoveryApprovals(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string, to string, agentMSPID string) ([]string, error) {
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := []string{agentMSPID}
+
+	approval, err := _readRecoveryApproval(ctx, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if approval != nil {
+		if approval.To != to {
+			return nil, conflictError("case %s recovers the token %s to %s", caseReference, tokenId, approval.To)
+		}
+		for _, mspID := range approval.Approvals {
+			if mspID != agentMSPID {
+				approvals = append(approvals, mspID)
+			}
+		}
+	}
+
+	quorum := metadata.StoredRecoveryQuorum()
+	if len(approvals) < quorum {
+		return nil, conflictError("case %s has %d of %d required approvals", caseReference, len(approvals), quorum)
+	}
+
+	if approval != nil {
+		approvalKey, err := _repository(ctx).key(recoveryApprovalPrefix, collectionId, caseReference, tokenId)
+		if err != nil {
+			return nil, err
+		}
+
+		err = _repository(ctx).delState(approvalKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return approvals, nil
+}
+
+func _readRecoveryApproval(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string) (*model.RecoveryApproval, error) {
+	approvalKey, err := _repository(ctx).key(recoveryApprovalPrefix, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	approvalBytes, err := _repository(ctx).getState(approvalKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(approvalBytes) == 0 {
+		return nil, nil
+	}
+
+	approval := new(model.RecoveryApproval)
+	err = json.Unmarshal(approvalBytes, approval)
+	if err != nil {
+		return nil, internalError("failed to unmarshal approvalBytes: %v", err)
+	}
+
+	return approval, nil
+}
+
+func _readRecoveryRecord(ctx contractapi.TransactionContextInterface, collectionId string, caseReference string, tokenId string) (*model.RecoveryRecord, error) {
+	recordKey, err := _repository(ctx).key(recoveryPrefix, collectionId, caseReference, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	recordBytes, err := _repository(ctx).getState(recordKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(recordBytes) == 0 {
+		return nil, nil
+	}
+
+	record := new(model.RecoveryRecord)
+	err = json.Unmarshal(recordBytes, record)
+	if err != nil {
+		return nil, internalError("failed to unmarshal recordBytes: %v", err)
+	}
+
+	return record, nil
+}
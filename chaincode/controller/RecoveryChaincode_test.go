@@ -0,0 +1,138 @@
+package chaincode
+
+import (
+	"crypto/x509/pkix"
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+// newRecoveryAgent returns a client its organization's CA issued with the recovery role
+func newRecoveryAgent(t *testing.T, mspID string, commonName string) testClient {
+	return newTestClientWith(t, mspID, pkix.Name{CommonName: commonName}, map[string]string{RecoveryRoleAttribute: RecoveryRole})
+}
+
+func TestApproveRecovery(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	org2Agent := newRecoveryAgent(t, "Org2MSP", "recovery2")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "SetRecoveryQuorum", "2")
+
+	tests := []struct {
+		name   string
+		client testClient
+		args   []string
+		want   ErrorCode
+	}{
+		{"client without the recovery role", bob, []string{"case-1", "1", bobID}, ErrCodeUnauthorized},
+		{"admin without the recovery role", admin, []string{"case-1", "1", bobID}, ErrCodeUnauthorized},
+		{"empty case reference", org2Agent, []string{"", "1", bobID}, ErrCodeInvalidArgument},
+		{"unknown token", org2Agent, []string{"case-1", "2", bobID}, ErrCodeTokenNotFound},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "ApproveRecovery", string(test.want), test.args...)
+		})
+	}
+
+	approval := &model.RecoveryApproval{}
+	if err := json.Unmarshal([]byte(ledger.ok(org2Agent, "ApproveRecovery", "case-1", "1", bobID)), approval); err != nil {
+		t.Fatal(err)
+	}
+	if len(approval.Approvals) != 1 || approval.Approvals[0] != "Org2MSP" {
+		t.Fatalf("approvals %q, want [Org2MSP]", approval.Approvals)
+	}
+	if ledger.event().EventName != RecoveryApprovedEventKey {
+		t.Fatalf("event %s, want %s", ledger.event().EventName, RecoveryApprovedEventKey)
+	}
+
+	// Every organization approves a case once, for a single recipient
+	ledger.fail(newRecoveryAgent(t, "Org2MSP", "recovery3"), "ApproveRecovery", string(ErrCodeConflict), "case-1", "1", bobID)
+	ledger.fail(newRecoveryAgent(t, "Org3MSP", "recovery4"), "ApproveRecovery", string(ErrCodeConflict), "case-1", "1", adminID)
+}
+
+func TestRecoveryQuorum(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	agent := newRecoveryAgent(t, AdminMSPID, "recovery1")
+	org2Agent := newRecoveryAgent(t, "Org2MSP", "recovery2")
+	org3Agent := newRecoveryAgent(t, "Org3MSP", "recovery3")
+	bobID := ledger.account(bob)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	for _, tokenId := range []string{"1", "2", "3"} {
+		ledger.ok(admin, "MintWithTokenURI", tokenId, "ipfs://deed/"+tokenId)
+		ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", tokenId, "")
+	}
+
+	ledger.fail(admin, "SetRecoveryQuorum", string(ErrCodeInvalidArgument), "0")
+	ledger.fail(bob, "SetRecoveryQuorum", string(ErrCodeUnauthorized), "2")
+	ledger.fail(bob, "CollectionSetRecoveryQuorum", string(ErrCodeUnauthorized), "deeds", "2")
+	ledger.fail(admin, "CollectionSetRecoveryQuorum", string(ErrCodeCollectionNotFound), "unknown", "2")
+
+	// The quorum of the legacy collection leaves the other collections alone
+	ledger.ok(admin, "SetRecoveryQuorum", "2")
+	ledger.ok(admin, "CollectionSetRecoveryQuorum", "deeds", "3")
+
+	tests := []struct {
+		name       string
+		collection string
+		tokenId    string
+		approvers  []testClient
+		want       ErrorCode
+	}{
+		{"legacy without approvals", "", "1", nil, ErrCodeConflict},
+		{"legacy with the agent organization only", "", "1", []testClient{newRecoveryAgent(t, AdminMSPID, "recovery4")}, ErrCodeConflict},
+		{"legacy quorum", "", "1", []testClient{org2Agent}, ""},
+		{"collection short of quorum", "deeds", "1", []testClient{org2Agent}, ErrCodeConflict},
+		{"collection quorum", "deeds", "2", []testClient{org2Agent, org3Agent}, ""},
+	}
+
+	for i, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			caseReference := "case-" + string(rune('a'+i))
+
+			for _, approver := range test.approvers {
+				if test.collection == "" {
+					ledger.ok(approver, "ApproveRecovery", caseReference, test.tokenId, bobID)
+				} else {
+					ledger.ok(approver, "CollectionApproveRecovery", test.collection, caseReference, test.tokenId, bobID)
+				}
+			}
+
+			function, args := "ForceTransfer", []string{test.tokenId, bobID, caseReference}
+			if test.collection != "" {
+				function, args = "CollectionForceTransfer", append([]string{test.collection}, args...)
+			}
+
+			if test.want != "" {
+				ledger.fail(agent, function, string(test.want), args...)
+				return
+			}
+
+			record := &model.RecoveryRecord{}
+			if err := json.Unmarshal([]byte(ledger.ok(agent, function, args...)), record); err != nil {
+				t.Fatal(err)
+			}
+			if len(record.Approvals) != len(test.approvers)+1 || record.Approvals[0] != AdminMSPID {
+				t.Fatalf("approvals %q", record.Approvals)
+			}
+			if ledger.event().EventName != ForcedTransferEventKey {
+				t.Fatalf("event %s, want %s", ledger.event().EventName, ForcedTransferEventKey)
+			}
+		})
+	}
+
+	ledger.expect(bob, bobID, "OwnerOf", "1")
+	ledger.expect(bob, bobID, "CollectionOwnerOf", "deeds", "2")
+
+	// Collections without a quorum of their own let the agent act alone
+	ledger.ok(admin, "CreateCollection", "titles", "Titles", "TITLE", "ipfs://titles/")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "titles", "1", "")
+	ledger.ok(agent, "CollectionForceTransfer", "titles", "1", bobID, "case-z")
+	ledger.fail(org2Agent, "CollectionForceTransfer", string(ErrCodeUnauthorized), "titles", "1", bobID, "case-y")
+}
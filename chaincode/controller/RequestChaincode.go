@@ -0,0 +1,149 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Transient field carrying the optional client request ID of a mint or transfer.
+// A retry with the same request ID returns the result of the completed request instead of running again.
+const RequestIdTransientKey = "requestId"
+
+/*
+`GetRequestStatus` is query fnc that returns the record of a request the requesting client completed with requestId
+*/
+func (c *TokenERC721Contract) GetRequestStatus(ctx contractapi.TransactionContextInterface, requestId string) (*model.RequestRecord, error) {
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := _readRequestRecord(ctx, client.ID, requestId)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, newContractError(ErrCodeRequestNotFound, "request %s does not exist", requestId)
+	}
+
+	return record, nil
+}
+
+// _replayRequest looks up the client request ID sent with the transaction, nil when none was sent.
+// When the request already completed its original result is decoded into result and replayed is true,
+// otherwise the returned record is handed to `_completeRequest` once the transaction did its work.
+func _replayRequest(ctx contractapi.TransactionContextInterface, result interface{}) (*model.RequestRecord, bool, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, false, internalError("failed to GetTransient: %v", err)
+	}
+
+	requestId, ok := transient[RequestIdTransientKey]
+	if !ok {
+		return nil, false, nil
+	}
+	if len(requestId) == 0 {
+		return nil, false, invalidArgumentError("requestId must not be empty")
+	}
+
+	client, err := _getClientAccount(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	function, _ := _calledFunction(ctx)
+
+	argsBytes, err := json.Marshal(ctx.GetStub().GetStringArgs())
+	if err != nil {
+		return nil, false, internalError("failed to marshal argsBytes: %v", err)
+	}
+	argsHash := sha256.Sum256(argsBytes)
+
+	request := &model.RequestRecord{
+		RequestId: string(requestId),
+		Client:    client.ID,
+		Function:  function,
+		ArgsHash:  hex.EncodeToString(argsHash[:]),
+	}
+
+	record, err := _readRequestRecord(ctx, request.Client, request.RequestId)
+	if err != nil {
+		return nil, false, err
+	}
+	if record == nil {
+		return request, false, nil
+	}
+
+	if record.Function != request.Function || record.ArgsHash != request.ArgsHash {
+		return nil, false, conflictError("request %s was completed by %s with other arguments", request.RequestId, record.Function)
+	}
+
+	err = json.Unmarshal([]byte(record.Result), result)
+	if err != nil {
+		return nil, false, internalError("failed to unmarshal the result of request %s: %v", request.RequestId, err)
+	}
+
+	return record, true, nil
+}
+
+// _completeRequest stores the dedupe record of a request with the result of its transaction, nothing when request is nil
+func _completeRequest(ctx contractapi.TransactionContextInterface, request *model.RequestRecord, result interface{}) error {
+	if request == nil {
+		return nil
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return internalError("failed to marshal resultBytes: %v", err)
+	}
+
+	now, err := _txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	request.Status = model.RequestCompleted
+	request.Result = string(resultBytes)
+	request.TxId = ctx.GetStub().GetTxID()
+	request.Timestamp = now
+
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return internalError("failed to marshal requestBytes: %v", err)
+	}
+
+	requestKey, err := ctx.GetStub().CreateCompositeKey(requestPrefix, []string{request.Client, request.RequestId})
+	if err != nil {
+		return internalError("failed to CreateCompositeKey requestKey: %v", err)
+	}
+
+	return _repository(ctx).putState(requestKey, requestBytes)
+}
+
+func _readRequestRecord(ctx contractapi.TransactionContextInterface, client string, requestId string) (*model.RequestRecord, error) {
+	requestKey, err := ctx.GetStub().CreateCompositeKey(requestPrefix, []string{client, requestId})
+	if err != nil {
+		return nil, internalError("failed to CreateCompositeKey requestKey: %v", err)
+	}
+
+	requestBytes, err := _repository(ctx).getState(requestKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(requestBytes) == 0 {
+		return nil, nil
+	}
+
+	record := new(model.RequestRecord)
+	err = json.Unmarshal(requestBytes, record)
+	if err != nil {
+		return nil, internalError("failed to unmarshal requestBytes: %v", err)
+	}
+
+	return record, nil
+}
@@ -0,0 +1,71 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+func TestRequestReplay(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	alice := newTestClient(t, "Org2MSP", "alice")
+	bob := newTestClient(t, "Org2MSP", "bob")
+	org2Admin := newOrgAdmin(t, "Org2MSP", "org2admin")
+	aliceID, bobID := ledger.account(alice), ledger.account(bob)
+
+	root, proofs := allowlistTree(aliceID, bobID, ledger.account(newTestClient(t, "Org3MSP", "carol")))
+	ledger.ok(admin, "SetMintAllowlistRoot", root, "presale")
+
+	ledger.ok(admin, "SetMintProposalPolicy", "1", "60")
+	proposal := proposeMint(ledger, org2Admin, "20", bobID)
+	other := proposeMint(ledger, org2Admin, "21", bobID)
+
+	tests := []struct {
+		name      string
+		client    testClient
+		function  string
+		args      []string
+		otherArgs []string
+		tokenId   string
+	}{
+		{"mint", admin, "MintWithTokenURI", []string{"10", "ipfs://deed/10"}, []string{"11", "ipfs://deed/11"}, "10"},
+		{"allowlist mint", alice, "MintAllowlisted", []string{"30", marshalProof(t, proofs[aliceID])}, []string{"31", marshalProof(t, proofs[aliceID])}, "30"},
+		{"mint proposal", org2Admin, "ExecuteMintProposal", []string{proposal.ProposalId}, []string{other.ProposalId}, "20"},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.transient = map[string][]byte{RequestIdTransientKey: []byte("request-" + test.tokenId)}
+			defer func() { ledger.transient = nil }()
+
+			first := ledger.ok(test.client, test.function, test.args...)
+			if len(ledger.events) == 0 {
+				t.Fatalf("%s emitted no event", test.function)
+			}
+
+			// The retry returns the original token without minting again
+			if retry := ledger.ok(test.client, test.function, test.args...); retry != first {
+				t.Fatalf("retry = %s, want %s", retry, first)
+			}
+			if len(ledger.events) != 0 {
+				t.Fatalf("retry emitted %d events", len(ledger.events))
+			}
+
+			ledger.fail(test.client, test.function, string(ErrCodeConflict), test.otherArgs...)
+
+			ledger.transient = nil
+			record := &model.RequestRecord{}
+			if err := json.Unmarshal([]byte(ledger.ok(test.client, "GetRequestStatus", "request-"+test.tokenId)), record); err != nil {
+				t.Fatal(err)
+			}
+			if record.Function != test.function || record.Status != model.RequestCompleted || record.Result != first {
+				t.Fatalf("record %+v", record)
+			}
+		})
+	}
+
+	ledger.expect(bob, "1", "AllowlistMintsOf", aliceID)
+	ledger.expect(bob, bobID, "OwnerOf", "20")
+	ledger.fail(bob, "OwnerOf", string(ErrCodeTokenNotFound), "31")
+	ledger.fail(bob, "GetRequestStatus", string(ErrCodeRequestNotFound), "request-10")
+}
@@ -0,0 +1,391 @@
+package chaincode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Object types moved by `ExportState` and `ImportState`, in export order.
+// Every composite key object type of the contract is listed, collections first so the records that follow find them.
+var exportedObjectTypes = []string{
+	collectionPrefix, nftPrefix, balancePrefix, approvalPrefix,
+	fractionPrefix, sharePrefix,
+	accountPrefix, accountAliasPrefix,
+	mintProposalPrefix, allowlistMintPrefix, mintCountPrefix, tokenIdCounterPrefix,
+	frozenAccountPrefix, frozenTokenPrefix,
+	recoveryPrefix, recoveryApprovalPrefix,
+	activityPrefix, requestPrefix,
+}
+
+// Object types stored as JSON documents, the others hold a marker byte, a counter or an account ID
+var jsonObjectTypes = map[string]bool{
+	collectionPrefix:       true,
+	nftPrefix:              true,
+	approvalPrefix:         true,
+	fractionPrefix:         true,
+	mintProposalPrefix:     true,
+	frozenAccountPrefix:    true,
+	frozenTokenPrefix:      true,
+	recoveryPrefix:         true,
+	recoveryApprovalPrefix: true,
+	activityPrefix:         true,
+	requestPrefix:          true,
+}
+
+/*
+`ExportState` is query fnc that returns a page of at most pageSize records of the contract state.
+The first page also carries the contract metadata; pass the returned bookmark to fetch the next page
+until it comes back empty. Every page can be handed to `ImportState` as is.
+*/
+func (c *TokenERC721Contract) ExportState(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string) (*model.StateBatch, error) {
+
+	if pageSize < 1 {
+		return nil, invalidArgumentError("pageSize must be a positive number")
+	}
+
+	batch := model.NewStateBatch()
+
+	if bookmark == "" {
+		metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+		if err != nil {
+			return nil, err
+		}
+		batch.Metadata = metadata
+	}
+
+	objectType, keyBookmark, err := parseExportBookmark(bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, exportedObjectType := range exportedObjectTypes {
+		if objectType != "" && exportedObjectType != objectType {
+			continue
+		}
+		objectType = ""
+
+		remaining := pageSize - len(batch.Records)
+
+		keyBookmark, err = _exportObjectType(ctx, batch, exportedObjectType, remaining, keyBookmark)
+		if err != nil {
+			return nil, err
+		}
+		if keyBookmark != "" {
+			batch.Bookmark = exportedObjectType + ":" + keyBookmark
+			return batch, nil
+		}
+		if len(batch.Records) == pageSize {
+			batch.Bookmark = _nextExportBookmark(exportedObjectType)
+			return batch, nil
+		}
+	}
+
+	return batch, nil
+}
+
+/*
+`ImportState` is invoke fnc that writes a page produced by `ExportState` into this contract.
+It is only allowed on a ledger without tokens, until the first token is minted here, and never overwrites existing records.
+The contract metadata is only taken from the first imported page, later pages cannot replace it.
+*/
+func (c *TokenERC721Contract) ImportState(ctx contractapi.TransactionContextInterface, batchJSON string) (int, error) {
+
+	firstImport, err := _isFirstImport(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	err = _checkImportAllowed(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := model.NewStateBatch()
+	err = json.Unmarshal([]byte(batchJSON), batch)
+	if err != nil {
+		return 0, invalidArgumentError("failed to Unmarshal batch: %v", err)
+	}
+
+	if batch.Metadata != nil {
+		if batch.Metadata.CollectionId != LegacyCollectionID {
+			return 0, invalidArgumentError("batch metadata must be the contract metadata")
+		}
+		if !firstImport {
+			return 0, conflictError("the contract metadata can only be imported with the first page")
+		}
+
+		err = _putCollectionMetadata(ctx, batch.Metadata)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, record := range batch.Records {
+		err = _importRecord(ctx, record)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(batch.Records), nil
+}
+
+// _exportObjectType appends up to pageSize records of objectType to the batch and
+// returns the ledger bookmark of the records left, or an empty one once objectType is exhausted
+func _exportObjectType(ctx contractapi.TransactionContextInterface, batch *model.StateBatch, objectType string, pageSize int, bookmark string) (string, error) {
+	var iterator shim.StateQueryIteratorInterface
+	var responseMetadata *pb.QueryResponseMetadata
+	var err error
+
+	// The activity index is keyed by simple keys, see _activityKey
+	if objectType == activityPrefix {
+		startKey, err := _activityKey(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		iterator, responseMetadata, err = ctx.GetStub().GetStateByRangeWithPagination(startKey, startKey+string(utf8.MaxRune), int32(pageSize), bookmark)
+		if err != nil {
+			return "", internalError("failed to GetStateByRangeWithPagination %s: %v", objectType, err)
+		}
+	} else {
+		iterator, responseMetadata, err = ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(objectType, []string{}, int32(pageSize), bookmark)
+		if err != nil {
+			return "", internalError("failed to GetStateByPartialCompositeKeyWithPagination %s: %v", objectType, err)
+		}
+	}
+	defer iterator.Close()
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return "", internalError("failed to iterate %s: %v", objectType, err)
+		}
+
+		compositeKey := kv.Key
+		if objectType == activityPrefix {
+			compositeKey = "\x00" + compositeKey
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(compositeKey)
+		if err != nil {
+			return "", internalError("failed to SplitCompositeKey %s: %v", kv.Key, err)
+		}
+
+		value, err := _exportValue(objectType, kv.Value)
+		if err != nil {
+			return "", err
+		}
+
+		record := model.NewStateRecord(objectType, attributes, value)
+
+		// Per-token endorsement policies live beside the nft records, not in their values
+		if objectType == nftPrefix {
+			policy, err := ctx.GetStub().GetStateValidationParameter(kv.Key)
+			if err != nil {
+				return "", internalError("failed to GetStateValidationParameter %s: %v", kv.Key, err)
+			}
+			record.ValidationParameter = base64.StdEncoding.EncodeToString(policy)
+		}
+
+		batch.Records = append(batch.Records, record)
+	}
+
+	if int(responseMetadata.GetFetchedRecordsCount()) < pageSize {
+		return "", nil
+	}
+
+	return responseMetadata.GetBookmark(), nil
+}
+
+// _exportValue returns a stored value as exported, binary records are exported as JSON
+func _exportValue(objectType string, value []byte) (string, error) {
+	if !model.IsBinaryEncoded(value) {
+		return string(value), nil
+	}
+
+	var record interface{}
+	var err error
+	switch objectType {
+	case nftPrefix:
+		nft := model.NewNFT("", "", "", "")
+		err = model.UnmarshalNFT(value, nft)
+		record = nft
+	case approvalPrefix:
+		approval := model.NewApproval("", "", false)
+		err = model.UnmarshalApproval(value, approval)
+		record = approval
+	default:
+		return string(value), nil
+	}
+	if err != nil {
+		return "", internalError("failed to Unmarshal %s: %v", objectType, err)
+	}
+
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return "", internalError("failed to marshal %s: %v", objectType, err)
+	}
+
+	return string(recordBytes), nil
+}
+
+// _nextExportBookmark starts the next page at the object type exported after objectType
+func _nextExportBookmark(objectType string) string {
+	for index, exportedObjectType := range exportedObjectTypes {
+		if exportedObjectType == objectType && index+1 < len(exportedObjectTypes) {
+			return exportedObjectTypes[index+1] + ":"
+		}
+	}
+	return ""
+}
+
+// _checkImportAllowed rejects an import after the first mint, or into a ledger holding tokens it did not import,
+// such as tokens minted before MintedKey was introduced
+func _checkImportAllowed(ctx contractapi.TransactionContextInterface) error {
+	repository := _repository(ctx)
+
+	mintedBytes, err := repository.getState(MintedKey)
+	if err != nil {
+		return err
+	}
+	if mintedBytes != nil {
+		return conflictError("state can only be imported before the first mint")
+	}
+
+	importedBytes, err := repository.getState(ImportedKey)
+	if err != nil {
+		return err
+	}
+	if importedBytes != nil {
+		return nil
+	}
+
+	hasTokens, err := repository.hasKeys(nftPrefix)
+	if err != nil {
+		return err
+	}
+	if hasTokens {
+		return conflictError("state can only be imported into a ledger without tokens")
+	}
+
+	return repository.putState(ImportedKey, []byte{'\u0000'})
+}
+
+// _isFirstImport reports whether no page was imported yet, or the pages imported so far left the contract metadata unset
+func _isFirstImport(ctx contractapi.TransactionContextInterface) (bool, error) {
+	repository := _repository(ctx)
+
+	importedBytes, err := repository.getState(ImportedKey)
+	if err != nil {
+		return false, err
+	}
+	if importedBytes == nil {
+		return true, nil
+	}
+
+	metadataBytes, err := repository.getState(InitialKey)
+	if err != nil {
+		return false, err
+	}
+
+	return metadataBytes == nil, nil
+}
+
+func _importRecord(ctx contractapi.TransactionContextInterface, record *model.StateRecord) error {
+	exported := false
+	for _, exportedObjectType := range exportedObjectTypes {
+		if record.ObjectType == exportedObjectType {
+			exported = true
+		}
+	}
+	if !exported {
+		return invalidArgumentError("unknown objectType %s", record.ObjectType)
+	}
+	// The token ID counter of the legacy collection is the only record keyed by its object type alone
+	if len(record.Attributes) == 0 && record.ObjectType != tokenIdCounterPrefix {
+		return invalidArgumentError("%s record has no attributes", record.ObjectType)
+	}
+	// An empty value would delete the key instead of writing it
+	if record.Value == "" {
+		return invalidArgumentError("%s record has no value", record.ObjectType)
+	}
+	if jsonObjectTypes[record.ObjectType] && !json.Valid([]byte(record.Value)) {
+		return invalidArgumentError("%s record value is not a JSON document", record.ObjectType)
+	}
+	policy, err := base64.StdEncoding.DecodeString(record.ValidationParameter)
+	if err != nil {
+		return invalidArgumentError("failed to decode validation parameter of %s: %v", strings.Join(record.Attributes, " "), err)
+	}
+	if len(policy) > 0 {
+		if record.ObjectType != nftPrefix {
+			return invalidArgumentError("%s record cannot have a validation parameter", record.ObjectType)
+		}
+		_, err = statebased.NewStateEP(policy)
+		if err != nil {
+			return invalidArgumentError("failed to parse validation parameter of %s: %v", strings.Join(record.Attributes, " "), err)
+		}
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(record.ObjectType, record.Attributes)
+	if err != nil {
+		return invalidArgumentError("failed to CreateCompositeKey %s: %v", record.ObjectType, err)
+	}
+	// The activity index is keyed by simple keys, see _activityKey
+	if record.ObjectType == activityPrefix {
+		key = key[1:]
+	}
+
+	repository := _repository(ctx)
+
+	// Reading through the repository also catches a key repeated within the batch
+	existingBytes, err := repository.getState(key)
+	if err != nil {
+		return err
+	}
+	if existingBytes != nil {
+		return conflictError("%s %s already exists", record.ObjectType, strings.Join(record.Attributes, " "))
+	}
+
+	err = repository.putState(key, []byte(record.Value))
+	if err != nil {
+		return err
+	}
+
+	if len(policy) > 0 {
+		err = ctx.GetStub().SetStateValidationParameter(key, policy)
+		if err != nil {
+			return internalError("failed to SetStateValidationParameter %s: %v", strings.Join(record.Attributes, " "), err)
+		}
+	}
+
+	return nil
+}
+
+// parseExportBookmark splits a bookmark of the form <objectType>:<ledger bookmark>
+func parseExportBookmark(bookmark string) (string, string, error) {
+	if bookmark == "" {
+		return "", "", nil
+	}
+
+	separator := strings.Index(bookmark, ":")
+	if separator < 0 {
+		return "", "", invalidArgumentError("malformed bookmark %s", bookmark)
+	}
+
+	for _, exportedObjectType := range exportedObjectTypes {
+		if bookmark[:separator] == exportedObjectType {
+			return bookmark[:separator], bookmark[separator+1:], nil
+		}
+	}
+
+	return "", "", invalidArgumentError("malformed bookmark %s", bookmark)
+}
@@ -0,0 +1,187 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"hyperledger_erc721/chaincode/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// compositeState returns the composite keys of the ledger with their values,
+// the simple keys of the activity index are returned as the composite keys they are derived from
+func compositeState(ledger *testLedger) map[string]string {
+	state := map[string]string{}
+	for key, value := range ledger.stub.State {
+		if strings.HasPrefix(key, activityPrefix+"\x00") {
+			key = "\x00" + key
+		}
+		if strings.HasPrefix(key, "\x00") {
+			state[key] = string(value)
+		}
+	}
+	return state
+}
+
+func TestExportImportState(t *testing.T) {
+	source, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := source.account(admin), source.account(bob)
+
+	// Touch every object type of the contract
+	source.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	source.ok(admin, "SetMintQuotas", "10", "10")
+	source.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	source.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+	source.ok(admin, "Mint", "ipfs://deed/3", adminID)
+	source.ok(admin, "CollectionMintWithTokenURI", "deeds", "1", "")
+	source.ok(admin, "SetApprovalForAll", bobID, "true")
+	source.ok(admin, "TransferFrom", adminID, bobID, "2")
+	source.ok(admin, "Fractionalize", "1", "10")
+	source.ok(admin, "TransferShares", "1", bobID, "4")
+	source.ok(admin, "RegisterAccount", bobID)
+	source.ok(admin, "FreezeAccount", bobID, "court order 1")
+	source.ok(admin, "FreezeToken", "3", "court order 2")
+	source.ok(admin, "SetTokenEndorsementPolicy", "1", `["Org1MSP","Org2MSP"]`)
+	source.ok(admin, "CollectionSetTokenEndorsementPolicy", "deeds", "1", `["Org3MSP"]`)
+	source.ok(newOrgAdmin(t, AdminMSPID, "org1admin"), "ProposeMint", "4", "ipfs://deed/4", adminID)
+	source.transient = map[string][]byte{RequestIdTransientKey: []byte("request-1")}
+	source.ok(admin, "MintWithTokenURI", "5", "ipfs://deed/5")
+	source.transient = nil
+
+	target := newTestLedger(t)
+	bookmark := ""
+	pages := 0
+	for {
+		pages++
+		batchJSON := source.ok(bob, "ExportState", "4", bookmark)
+		target.ok(admin, "ImportState", batchJSON)
+
+		batch := model.NewStateBatch()
+		if err := json.Unmarshal([]byte(batchJSON), batch); err != nil {
+			t.Fatal(err)
+		}
+		if (pages == 1) != (batch.Metadata != nil) {
+			t.Fatalf("page %d carries metadata %v", pages, batch.Metadata)
+		}
+		if batch.Bookmark == "" {
+			break
+		}
+		bookmark = batch.Bookmark
+	}
+
+	sourceState, targetState := compositeState(source), compositeState(target)
+	for key, value := range sourceState {
+		objectType, _, err := source.stub.SplitCompositeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if targetState[key] != value {
+			t.Errorf("%s record %q was not copied", objectType, key)
+		}
+	}
+	if len(targetState) != len(sourceState) {
+		t.Fatalf("%d records imported, want %d", len(targetState), len(sourceState))
+	}
+
+	target.expect(admin, "HLF721", "Name")
+	target.expect(admin, bobID, "OwnerOf", "2")
+	target.expect(admin, "true", "IsApprovedForAll", adminID, bobID)
+	target.expect(admin, "4", "SharesOf", "1", bobID)
+	target.expect(admin, "true", "IsFrozen", bobID)
+	target.expect(admin, "1", "CollectionTotalSupply", "deeds")
+	target.expect(admin, `["Org1MSP","Org2MSP"]`, "GetTokenEndorsementPolicy", "1")
+	target.expect(admin, `["Org3MSP"]`, "CollectionGetTokenEndorsementPolicy", "deeds", "1")
+	target.expect(admin, "[]", "GetTokenEndorsementPolicy", "2")
+	if got, want := activityPages(target, bob, bobID, 0, 0, 10), activityPages(source, bob, bobID, 0, 0, 10); len(want[0]) == 0 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("activity pages %v, want %v", got, want)
+	}
+	target.fail(admin, "ImportState", string(ErrCodeConflict), source.ok(bob, "ExportState", "4", ""))
+
+	target.ok(admin, "MintWithTokenURI", "9", "ipfs://deed/9")
+	target.fail(admin, "ImportState", "before the first mint", "{}")
+}
+
+func TestImportStateGuard(t *testing.T) {
+	tests := []struct {
+		name      string
+		prepare   func(ledger *testLedger, admin testClient)
+		client    func(t *testing.T) testClient
+		batchJSON string
+		want      string
+	}{
+		{
+			name:      "not an admin",
+			client:    func(t *testing.T) testClient { return newTestClient(t, "Org2MSP", "bob") },
+			batchJSON: "{}",
+			want:      string(ErrCodeUnauthorized),
+		},
+		{
+			name: "after the first mint",
+			prepare: func(ledger *testLedger, admin testClient) {
+				ledger.ok(admin, "Initialize", "HLF721", "HLF", "0")
+				ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+			},
+			batchJSON: "{}",
+			want:      "before the first mint",
+		},
+		{
+			name: "tokens minted before the minted key",
+			prepare: func(ledger *testLedger, admin testClient) {
+				ledger.seed(nftPrefix, []string{"1"}, model.NewNFT("1", "x509::CN=bob::CN=bob", "", "ipfs://deed/1"))
+			},
+			batchJSON: "{}",
+			want:      "without tokens",
+		},
+		{
+			name: "metadata after the first page",
+			prepare: func(ledger *testLedger, admin testClient) {
+				ledger.ok(admin, "ImportState", `{"metadata":{"name":"HLF721","symbol":"HLF"}}`)
+			},
+			batchJSON: `{"metadata":{"name":"Other","symbol":"OTH","paused":true}}`,
+			want:      "first page",
+		},
+		{
+			name:      "unknown object type",
+			batchJSON: `{"records":[{"objectType":"unknown","attributes":["1"],"value":"{}"}]}`,
+			want:      string(ErrCodeInvalidArgument),
+		},
+		{
+			name:      "malformed document",
+			batchJSON: `{"records":[{"objectType":"nft","attributes":["1"],"value":"not json"}]}`,
+			want:      string(ErrCodeInvalidArgument),
+		},
+		{
+			name:      "validation parameter of a balance",
+			batchJSON: `{"records":[{"objectType":"balance","attributes":["","a","1"],"value":"\u0000","validationParameter":"AQID"}]}`,
+			want:      "validation parameter",
+		},
+		{
+			name:      "malformed validation parameter",
+			batchJSON: `{"records":[{"objectType":"nft","attributes":["1"],"value":"{}","validationParameter":"AQID"}]}`,
+			want:      "validation parameter",
+		},
+		{
+			name:      "repeated key",
+			batchJSON: `{"records":[{"objectType":"share","attributes":["1","a"],"value":"1"},{"objectType":"share","attributes":["1","a"],"value":"2"}]}`,
+			want:      string(ErrCodeConflict),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ledger := newTestLedger(t)
+			admin := newTestClient(t, AdminMSPID, "admin")
+
+			if test.prepare != nil {
+				test.prepare(ledger, admin)
+			}
+			client := admin
+			if test.client != nil {
+				client = test.client(t)
+			}
+
+			ledger.fail(client, "ImportState", test.want, test.batchJSON)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package chaincode
+
+import (
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Mint counters are single keys every limited mint reads and rewrites, so concurrent mints
+// against the same limit fail MVCC validation instead of all passing the check and overshooting it.
+// Counters are only kept while their limit is set, which keeps unlimited mints free of a shared key.
+const (
+	supplyCounter  = "supply"
+	accountCounter = "account"
+	mspCounter     = "msp"
+)
+
+/*
+`SetMintQuotas` is invoke fnc that limits how many tokens, across all collections, can be minted to a single account
+and by the clients of a single MSP, 0 for no limit.
+Quotas count the mints made while they are set.
+*/
+func (c *TokenERC721Contract) SetMintQuotas(ctx contractapi.TransactionContextInterface, accountQuota int, mspQuota int) (bool, error) {
+
+	if accountQuota < 0 || mspQuota < 0 {
+		return false, invalidArgumentError("quotas must not be negative")
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, LegacyCollectionID)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.AccountMintQuota = accountQuota
+	metadata.MSPMintQuota = mspQuota
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`CollectionSetMaxSupply` is invoke fnc that limits how many tokens can be minted in a collection, 0 for no limit.
+The legacy collection starts with the maxSupply passed to `Initialize`.
+When a limit is set on a collection that had none, minting continues from the count of its tokens on the ledger,
+which leaves out tokens burned while the collection had no limit.
+*/
+func (c *TokenERC721Contract) CollectionSetMaxSupply(ctx contractapi.TransactionContextInterface, collectionId string, maxSupply int) (bool, error) {
+
+	if maxSupply < 0 {
+		return false, invalidArgumentError("maxSupply must not be negative")
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return false, err
+	}
+
+	// The counter is not kept up to date while the collection has no limit
+	if metadata.MaxSupply == 0 && maxSupply > 0 {
+		counted, err := _readMintCounter(ctx, _supplyCounterAttributes(collectionId)...)
+		if err != nil {
+			return false, err
+		}
+
+		live, err := _repository(ctx).CountNFTs(collectionId)
+		if err != nil {
+			return false, err
+		}
+
+		if live > counted {
+			err = _putMintCounter(ctx, live, _supplyCounterAttributes(collectionId)...)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	metadata.MaxSupply = maxSupply
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+/*
+`RemainingSupply` is query fnc that returns how many more tokens can be minted, -1 when the supply is not limited
+*/
+func (c *TokenERC721Contract) RemainingSupply(ctx contractapi.TransactionContextInterface) (int, error) {
+	return c.remainingSupply(ctx, LegacyCollectionID)
+}
+
+/*
+`CollectionRemainingSupply` is query fnc that returns how many more tokens can be minted in a collection,
+-1 when its supply is not limited
+*/
+func (c *TokenERC721Contract) CollectionRemainingSupply(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+	return c.remainingSupply(ctx, collectionId)
+}
+
+func (c *TokenERC721Contract) remainingSupply(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return 0, err
+	}
+	if metadata.MaxSupply == 0 {
+		return -1, nil
+	}
+
+	minted, err := _readSupplyCounter(ctx, collectionId)
+	if err != nil {
+		return 0, err
+	}
+	if minted >= metadata.MaxSupply {
+		return 0, nil
+	}
+
+	return metadata.MaxSupply - minted, nil
+}
+
+// _countMint checks a mint to owner against the max supply of the collection and the contract-wide quotas and counts it.
+// The quota of the MSP is charged to the client submitting the mint.
+func _countMint(ctx contractapi.TransactionContextInterface, collectionId string, owner string) error {
+	collection, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return err
+	}
+
+	metadata := collection
+	if collectionId != LegacyCollectionID {
+		metadata, err = _readCollectionMetadata(ctx, LegacyCollectionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Counters are only written once every limit is checked
+	type mintCounter struct {
+		minted     int
+		attributes []string
+	}
+	var counters []mintCounter
+
+	if collection.MaxSupply > 0 {
+		minted, err := _readSupplyCounter(ctx, collectionId)
+		if err != nil {
+			return err
+		}
+		if minted >= collection.MaxSupply {
+			return newContractError(ErrCodeMintLimitReached, "the max supply of %d tokens is reached", collection.MaxSupply)
+		}
+		counters = append(counters, mintCounter{minted, _supplyCounterAttributes(collectionId)})
+	}
+
+	if metadata.AccountMintQuota > 0 {
+		minted, err := _readMintCounter(ctx, accountCounter, owner)
+		if err != nil {
+			return err
+		}
+		if minted >= metadata.AccountMintQuota {
+			return newContractError(ErrCodeMintLimitReached, "account %s reached its quota of %d tokens", owner, metadata.AccountMintQuota)
+		}
+		counters = append(counters, mintCounter{minted, []string{accountCounter, owner}})
+	}
+
+	if metadata.MSPMintQuota > 0 {
+		clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return internalError("failed to get clientMSPID: %v", err)
+		}
+
+		minted, err := _readMintCounter(ctx, mspCounter, clientMSPID)
+		if err != nil {
+			return err
+		}
+		if minted >= metadata.MSPMintQuota {
+			return newContractError(ErrCodeMintLimitReached, "%s reached its quota of %d tokens", clientMSPID, metadata.MSPMintQuota)
+		}
+		counters = append(counters, mintCounter{minted, []string{mspCounter, clientMSPID}})
+	}
+
+	for _, counter := range counters {
+		err = _putMintCounter(ctx, counter.minted+1, counter.attributes...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// _readSupplyCounter reads the number of tokens minted in a collection, burned tokens included.
+// Until the first limited mint writes the counter, for instance after `ImportState`,
+// the tokens of the collection on the ledger are counted instead, which leaves out the burned ones.
+func _readSupplyCounter(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+	counterKey, err := ctx.GetStub().CreateCompositeKey(mintCountPrefix, _supplyCounterAttributes(collectionId))
+	if err != nil {
+		return 0, internalError("failed to CreateCompositeKey counterKey: %v", err)
+	}
+
+	counterBytes, err := _repository(ctx).getState(counterKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(counterBytes) > 0 {
+		return parseMintCounter(counterBytes)
+	}
+
+	return _repository(ctx).CountNFTs(collectionId)
+}
+
+// _supplyCounterAttributes keeps the counter of the legacy collection under its original key
+func _supplyCounterAttributes(collectionId string) []string {
+	if collectionId == LegacyCollectionID {
+		return []string{supplyCounter}
+	}
+	return []string{supplyCounter, collectionId}
+}
+
+func _readMintCounter(ctx contractapi.TransactionContextInterface, attributes ...string) (int, error) {
+	counterKey, err := ctx.GetStub().CreateCompositeKey(mintCountPrefix, attributes)
+	if err != nil {
+		return 0, internalError("failed to CreateCompositeKey counterKey: %v", err)
+	}
+
+	counterBytes, err := _repository(ctx).getState(counterKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(counterBytes) == 0 {
+		return 0, nil
+	}
+
+	return parseMintCounter(counterBytes)
+}
+
+func _putMintCounter(ctx contractapi.TransactionContextInterface, minted int, attributes ...string) error {
+	counterKey, err := ctx.GetStub().CreateCompositeKey(mintCountPrefix, attributes)
+	if err != nil {
+		return internalError("failed to CreateCompositeKey counterKey: %v", err)
+	}
+
+	return _repository(ctx).putState(counterKey, []byte(strconv.Itoa(minted)))
+}
+
+func parseMintCounter(counterBytes []byte) (int, error) {
+	minted, err := strconv.Atoi(string(counterBytes))
+	if err != nil {
+		return 0, internalError("failed to parse counterBytes: %v", err)
+	}
+
+	return minted, nil
+}
@@ -0,0 +1,116 @@
+package chaincode
+
+import "testing"
+
+func TestMaxSupply(t *testing.T) {
+	ledger := newTestLedger(t)
+	admin := newTestClient(t, AdminMSPID, "admin")
+	adminID := ledger.account(admin)
+
+	ledger.fail(admin, "Initialize", string(ErrCodeInvalidArgument), "HLF721", "HLF", "-1")
+	ledger.ok(admin, "Initialize", "HLF721", "HLF", "2")
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+
+	ledger.expect(admin, "2", "RemainingSupply")
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+	ledger.ok(admin, "Burn", "1")
+
+	// Burned tokens stay counted
+	ledger.expect(admin, "1", "RemainingSupply")
+	ledger.ok(admin, "Mint", "ipfs://deed/2", adminID)
+	ledger.fail(admin, "MintWithTokenURI", string(ErrCodeMintLimitReached), "3", "ipfs://deed/3")
+	ledger.expect(admin, "0", "RemainingSupply")
+
+	// The cap of the legacy collection leaves other collections alone
+	ledger.expect(admin, "-1", "CollectionRemainingSupply", "deeds")
+	for _, tokenId := range []string{"1", "2", "3"} {
+		ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", tokenId, "")
+	}
+}
+
+func TestCollectionSetMaxSupply(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+
+	tests := []struct {
+		name         string
+		client       testClient
+		collectionId string
+		maxSupply    string
+		want         ErrorCode
+	}{
+		{"not an admin", bob, "deeds", "3", ErrCodeUnauthorized},
+		{"negative", admin, "deeds", "-1", ErrCodeInvalidArgument},
+		{"unknown collection", admin, "lands", "3", ErrCodeCollectionNotFound},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, "CollectionSetMaxSupply", string(test.want), test.collectionId, test.maxSupply)
+		})
+	}
+
+	for _, tokenId := range []string{"1", "2", "3"} {
+		ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", tokenId, "")
+	}
+	ledger.ok(admin, "CollectionBurn", "deeds", "3")
+
+	// A limit set later starts from the tokens on the ledger, leaving out those burned without a limit
+	ledger.ok(admin, "CollectionSetMaxSupply", "deeds", "3")
+	ledger.expect(admin, "1", "CollectionRemainingSupply", "deeds")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "4", "")
+	ledger.fail(admin, "CollectionMintWithTokenURI", string(ErrCodeMintLimitReached), "deeds", "5", "")
+
+	// Raising the limit keeps the count
+	ledger.ok(admin, "CollectionSetMaxSupply", "deeds", "4")
+	ledger.expect(admin, "1", "CollectionRemainingSupply", "deeds")
+
+	ledger.ok(admin, "CollectionSetMaxSupply", "deeds", "0")
+	ledger.expect(admin, "-1", "CollectionRemainingSupply", "deeds")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "5", "")
+	ledger.ok(admin, "CollectionMintWithTokenURI", "deeds", "6", "")
+
+	// The count catches up with the five tokens on the ledger, including those minted without a limit
+	ledger.ok(admin, "CollectionSetMaxSupply", "deeds", "6")
+	ledger.expect(admin, "1", "CollectionRemainingSupply", "deeds")
+	ledger.expect(admin, "-1", "RemainingSupply")
+}
+
+func TestMintQuotas(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	admin2 := newTestClient(t, AdminMSPID, "admin2")
+	bob := newTestClient(t, "Org2MSP", "bob")
+	adminID, bobID := ledger.account(admin), ledger.account(bob)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.fail(bob, "SetMintQuotas", string(ErrCodeUnauthorized), "1", "3")
+	ledger.fail(admin, "SetMintQuotas", string(ErrCodeInvalidArgument), "-1", "3")
+	ledger.ok(admin, "SetMintQuotas", "1", "3")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     string
+	}{
+		{"first mint to admin", admin, "Mint", []string{"ipfs://deed/1", adminID}, ""},
+		{"account quota", admin, "CollectionMint", []string{"deeds", "ipfs://deeds/1", adminID}, string(ErrCodeMintLimitReached)},
+		{"first mint to bob in a collection", admin, "CollectionMint", []string{"deeds", "ipfs://deeds/2", bobID}, ""},
+		{"account quota across collections", admin2, "Mint", []string{"ipfs://deed/3", bobID}, string(ErrCodeMintLimitReached)},
+		{"third mint of the msp", admin2, "MintWithTokenURI", []string{"4", "ipfs://deed/4"}, ""},
+		{"msp quota", admin2, "CollectionMintWithTokenURI", []string{"deeds", "5", ""}, string(ErrCodeMintLimitReached)},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			if test.want == "" {
+				ledger.ok(test.client, test.function, test.args...)
+			} else {
+				ledger.fail(test.client, test.function, test.want, test.args...)
+			}
+		})
+	}
+}
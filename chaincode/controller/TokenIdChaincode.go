@@ -0,0 +1,163 @@
+package chaincode
+
+import (
+	"hyperledger_erc721/chaincode/model"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+`SetTokenIdScheme` is invoke fnc that selects how `Mint` assigns token IDs, sequential or txhash
+*/
+func (c *TokenERC721Contract) SetTokenIdScheme(ctx contractapi.TransactionContextInterface, scheme string) (bool, error) {
+	return c.setTokenIdScheme(ctx, LegacyCollectionID, scheme)
+}
+
+/*
+`TokenIdScheme` is query fnc that returns how `Mint` assigns token IDs
+*/
+func (c *TokenERC721Contract) TokenIdScheme(ctx contractapi.TransactionContextInterface) (string, error) {
+	return c.tokenIdScheme(ctx, LegacyCollectionID)
+}
+
+/*
+`Mint` is invoke fnc that mints a new non-fungible token to an account under a token ID assigned by the contract.
+The assigned ID is returned in the token and in the Transfer event.
+*/
+func (c *TokenERC721Contract) Mint(ctx contractapi.TransactionContextInterface, tokenURI string, to string) (*model.NFT, error) {
+	return c.mint(ctx, LegacyCollectionID, tokenURI, to)
+}
+
+func (c *TokenERC721Contract) setTokenIdScheme(ctx contractapi.TransactionContextInterface, collectionId string, scheme string) (bool, error) {
+
+	if !model.IsValidTokenIdScheme(scheme) {
+		return false, invalidArgumentError("unknown token ID scheme %s", scheme)
+	}
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return false, err
+	}
+
+	metadata.TokenIdScheme = scheme
+
+	err = _putCollectionMetadata(ctx, metadata)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *TokenERC721Contract) tokenIdScheme(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	return metadata.StoredTokenIdScheme(), nil
+}
+
+func (c *TokenERC721Contract) mint(ctx contractapi.TransactionContextInterface, collectionId string, tokenURI string, to string) (*model.NFT, error) {
+
+	if to == "" {
+		return nil, invalidArgumentError("to must not be empty")
+	}
+
+	nft := new(model.NFT)
+	request, replayed, err := _replayRequest(ctx, nft)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return nft, nil
+	}
+
+	err = _validateRecipient(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId, err := _nextTokenId(ctx, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	nft, err = _mint(ctx, collectionId, tokenId, tokenURI, "", "", to)
+	if err != nil {
+		return nil, err
+	}
+
+	err = _completeRequest(ctx, request, nft)
+	if err != nil {
+		return nil, err
+	}
+
+	return nft, nil
+}
+
+// _nextTokenId assigns the next free token ID of a collection with its token ID scheme.
+// The sequential counter is a single key, so concurrent mints into a collection fail on MVCC conflicts rather than collide.
+func _nextTokenId(ctx contractapi.TransactionContextInterface, collectionId string) (string, error) {
+	metadata, err := _readCollectionMetadata(ctx, collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	repository := _repository(ctx)
+
+	if metadata.StoredTokenIdScheme() == model.TxHashTokenIds {
+		// The index tells apart several mints of the same transaction
+		for index := 0; ; index++ {
+			tokenId := model.TxHashTokenId(ctx.GetStub().GetTxID(), index)
+
+			exists, err := repository.NFTExists(collectionId, tokenId)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				return tokenId, nil
+			}
+		}
+	}
+
+	counterKey, err := repository.key(tokenIdCounterPrefix, collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	counterBytes, err := repository.getState(counterKey)
+	if err != nil {
+		return "", err
+	}
+
+	counter := 0
+	if len(counterBytes) > 0 {
+		counter, err = strconv.Atoi(string(counterBytes))
+		if err != nil {
+			return "", internalError("failed to parse counterBytes: %v", err)
+		}
+	}
+
+	// IDs minted by hand with `MintWithTokenURI` are skipped
+	for {
+		counter++
+
+		exists, err := repository.NFTExists(collectionId, strconv.Itoa(counter))
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			break
+		}
+	}
+
+	err = repository.putState(counterKey, []byte(strconv.Itoa(counter)))
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(counter), nil
+}
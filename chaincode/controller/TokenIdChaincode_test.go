@@ -0,0 +1,117 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"hyperledger_erc721/chaincode/model"
+	"testing"
+)
+
+// mintAssigned mints with a contract-assigned token ID and returns the token
+func mintAssigned(ledger *testLedger, client testClient, function string, args ...string) *model.NFT {
+	ledger.t.Helper()
+
+	nft := new(model.NFT)
+	if err := json.Unmarshal([]byte(ledger.ok(client, function, args...)), nft); err != nil {
+		ledger.t.Fatal(err)
+	}
+
+	transfer := new(model.Transfer)
+	if err := json.Unmarshal(ledger.event().Payload, transfer); err != nil {
+		ledger.t.Fatal(err)
+	}
+	if transfer.TokenId != nft.TokenId || transfer.From != ZeroAddress {
+		ledger.t.Fatalf("transfer %+v of token %s", transfer, nft.TokenId)
+	}
+
+	return nft
+}
+
+func TestSetTokenIdScheme(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"unknown scheme", admin, "SetTokenIdScheme", []string{"uuid"}, ErrCodeInvalidArgument},
+		{"empty scheme", admin, "SetTokenIdScheme", []string{""}, ErrCodeInvalidArgument},
+		{"not an admin", bob, "SetTokenIdScheme", []string{model.TxHashTokenIds}, ErrCodeUnauthorized},
+		{"collection not an admin", bob, "CollectionSetTokenIdScheme", []string{"deeds", model.TxHashTokenIds}, ErrCodeUnauthorized},
+		{"unknown collection", admin, "CollectionSetTokenIdScheme", []string{"unknown", model.TxHashTokenIds}, ErrCodeCollectionNotFound},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	ledger.expect(bob, model.SequentialTokenIds, "TokenIdScheme")
+	ledger.expect(bob, model.SequentialTokenIds, "CollectionTokenIdScheme", "deeds")
+
+	// Every collection keeps its own scheme
+	ledger.ok(admin, "SetTokenIdScheme", model.TxHashTokenIds)
+	ledger.expect(bob, model.TxHashTokenIds, "TokenIdScheme")
+	ledger.expect(bob, model.SequentialTokenIds, "CollectionTokenIdScheme", "deeds")
+
+	ledger.ok(admin, "CollectionSetTokenIdScheme", "deeds", model.TxHashTokenIds)
+	ledger.ok(admin, "SetTokenIdScheme", model.SequentialTokenIds)
+	ledger.expect(bob, model.SequentialTokenIds, "TokenIdScheme")
+	ledger.expect(bob, model.TxHashTokenIds, "CollectionTokenIdScheme", "deeds")
+}
+
+func TestMint(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	bob := newTestClient(t, "Org2MSP", "bob")
+	bobID := ledger.account(bob)
+
+	ledger.ok(admin, "CreateCollection", "deeds", "Deeds", "DEED", "ipfs://deeds/")
+	ledger.ok(admin, "CreateCollection", "titles", "Titles", "TITLE", "ipfs://titles/")
+	ledger.ok(admin, "CollectionSetTokenIdScheme", "titles", model.TxHashTokenIds)
+
+	// IDs minted by hand are skipped by the sequential scheme
+	ledger.ok(admin, "MintWithTokenURI", "2", "ipfs://deed/2")
+
+	ledger.fail(admin, "Mint", string(ErrCodeInvalidArgument), "ipfs://deed/x", "")
+	ledger.fail(bob, "Mint", string(ErrCodeUnauthorized), "ipfs://deed/x", bobID)
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+		// want is the assigned ID, the tx hash ID of the transaction when empty
+		want string
+	}{
+		{"first sequential", "Mint", []string{"ipfs://deed/1", bobID}, "1"},
+		{"skips minted ID", "Mint", []string{"ipfs://deed/3", bobID}, "3"},
+		{"next sequential", "Mint", []string{"ipfs://deed/4", bobID}, "4"},
+		{"collection counter", "CollectionMint", []string{"deeds", "ipfs://deeds/1", bobID}, "1"},
+		{"collection tx hash", "CollectionMint", []string{"titles", "ipfs://titles/a", bobID}, ""},
+		{"next collection tx hash", "CollectionMint", []string{"titles", "ipfs://titles/b", bobID}, ""},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			want := test.want
+			if want == "" {
+				want = model.TxHashTokenId(fmt.Sprintf("tx%d", ledger.txCount+1), 0)
+			}
+
+			nft := mintAssigned(ledger, admin, test.function, test.args...)
+			if nft.TokenId != want || nft.Owner != bobID {
+				t.Fatalf("minted %+v, want token %s of %s", nft, want, bobID)
+			}
+		})
+	}
+
+	ledger.expect(bob, bobID, "OwnerOf", "4")
+	ledger.expect(bob, "ipfs://deeds/1", "CollectionTokenURI", "deeds", "1")
+	ledger.expect(bob, "2", "CollectionBalanceOf", "titles", bobID)
+}
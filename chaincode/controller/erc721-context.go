@@ -0,0 +1,268 @@
+package chaincode
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransactionContext is the context handed to every transaction of TokenERC721Contract.
+// It caches the calling client's account so it is resolved at most once per transaction,
+// and the Repository every read and write of the transaction goes through.
+type TransactionContext struct {
+	contractapi.TransactionContext
+	account    *clientAccount
+	repository *Repository
+}
+
+// transactionPolicy declares the checks `BeforeTransaction` runs before a transaction is executed
+type transactionPolicy struct {
+	// initialized requires `Initialize`, or `CreateCollection` of the collection
+	// passed as first argument when collectionScoped is set
+	initialized      bool
+	collectionScoped bool
+	// admin restricts the transaction to clients of AdminMSPID
+	admin bool
+	// orgAdmin restricts the transaction to the admins of the client's own organization
+	orgAdmin bool
+	// pausable rejects the transaction while the contract is paused
+	pausable bool
+	// recovery restricts the transaction to the recovery agents of AdminMSPID
+	recovery bool
+	// recoveryApprover restricts the transaction to the recovery agents of any organization
+	recoveryApprover bool
+}
+
+// Policies of the transactions, transactions missing here are rejected.
+// An empty policy exempts a transaction from every check.
+var transactionPolicies = map[string]transactionPolicy{
+	"ClientAccountID": {},
+	"Initialize":      {admin: true},
+	"Name":            {initialized: true},
+	"Symbol":          {initialized: true},
+
+	"TransferFrom":        {initialized: true, pausable: true},
+	"MintWithTokenURI":    {initialized: true, admin: true, pausable: true},
+	"MintWithContentHash": {initialized: true, admin: true, pausable: true},
+	"Mint":                {initialized: true, admin: true, pausable: true},
+	"MintWithAttributes":  {initialized: true, admin: true, pausable: true},
+	"SetTokenAttributes":  {initialized: true, admin: true, pausable: true},
+	"SetMetadataSchema":   {initialized: true, admin: true},
+	"GetMetadataSchema":   {initialized: true},
+	"VerifyContent":       {initialized: true},
+	"Approve":             {initialized: true, pausable: true},
+	"SetOperatorApproval": {initialized: true, pausable: true},
+	"GetOperatorApproval": {initialized: true},
+	"GetOperatorsOf":      {initialized: true},
+	"GetApprovedTokensOf": {initialized: true},
+	"RevokeAllApprovals":  {initialized: true},
+	"SetApprovalForAll":   {initialized: true, pausable: true},
+	"Burn":                {initialized: true, pausable: true},
+
+	"BalanceOf":            {initialized: true},
+	"OwnerOf":              {initialized: true},
+	"IsApprovedForAll":     {initialized: true},
+	"GetApproved":          {initialized: true},
+	"TokenURI":             {initialized: true},
+	"TotalSupply":          {initialized: true},
+	"ClientAccountBalance": {initialized: true},
+	"GetAccountActivity":   {initialized: true},
+	"GetRequestStatus":     {initialized: true},
+
+	"CreateCollection":              {admin: true},
+	"CollectionName":                {initialized: true, collectionScoped: true},
+	"CollectionSymbol":              {initialized: true, collectionScoped: true},
+	"CollectionTotalSupply":         {initialized: true, collectionScoped: true},
+	"CollectionBalanceOf":           {initialized: true, collectionScoped: true},
+	"CollectionOwnerOf":             {initialized: true, collectionScoped: true},
+	"CollectionTokenURI":            {initialized: true, collectionScoped: true},
+	"CollectionGetApproved":         {initialized: true, collectionScoped: true},
+	"CollectionIsApprovedForAll":    {initialized: true, collectionScoped: true},
+	"CollectionMintWithTokenURI":    {initialized: true, collectionScoped: true, admin: true, pausable: true},
+	"CollectionMintWithContentHash": {initialized: true, collectionScoped: true, admin: true, pausable: true},
+	"CollectionMint":                {initialized: true, collectionScoped: true, admin: true, pausable: true},
+	"CollectionMintWithAttributes":  {initialized: true, collectionScoped: true, admin: true, pausable: true},
+	"CollectionSetTokenAttributes":  {initialized: true, collectionScoped: true, admin: true, pausable: true},
+	"CollectionSetMetadataSchema":   {initialized: true, collectionScoped: true, admin: true},
+	"CollectionGetMetadataSchema":   {initialized: true, collectionScoped: true},
+	"CollectionSetTokenIdScheme":    {initialized: true, collectionScoped: true, admin: true},
+	"CollectionTokenIdScheme":       {initialized: true, collectionScoped: true},
+	"CollectionVerifyContent":       {initialized: true, collectionScoped: true},
+	"CollectionTransferFrom":        {initialized: true, collectionScoped: true, pausable: true},
+	"CollectionApprove":             {initialized: true, collectionScoped: true, pausable: true},
+	"CollectionSetApprovalForAll":   {initialized: true, collectionScoped: true, pausable: true},
+	"CollectionSetOperatorApproval": {initialized: true, collectionScoped: true, pausable: true},
+	"CollectionGetOperatorApproval": {initialized: true, collectionScoped: true},
+	"CollectionGetOperatorsOf":      {initialized: true, collectionScoped: true},
+	"CollectionGetApprovedTokensOf": {initialized: true, collectionScoped: true},
+	"CollectionBurn":                {initialized: true, collectionScoped: true, pausable: true},
+	"CollectionFreezeToken":         {initialized: true, collectionScoped: true, admin: true},
+	"CollectionUnfreezeToken":       {initialized: true, collectionScoped: true, admin: true},
+	"CollectionIsTokenFrozen":       {initialized: true, collectionScoped: true},
+	"CollectionApproveRecovery":     {initialized: true, collectionScoped: true, recoveryApprover: true},
+	"CollectionForceTransfer":       {initialized: true, collectionScoped: true, recovery: true},
+	"CollectionGetRecoveryRecord":   {initialized: true, collectionScoped: true},
+	"CollectionSetRecoveryQuorum":   {initialized: true, collectionScoped: true, admin: true},
+
+	"CollectionSetTokenEndorsementPolicy": {initialized: true, collectionScoped: true, admin: true},
+	"CollectionGetTokenEndorsementPolicy": {initialized: true, collectionScoped: true},
+
+	"Fractionalize":  {initialized: true, pausable: true},
+	"TransferShares": {initialized: true, pausable: true},
+	"SharesOf":       {initialized: true},
+	"Redeem":         {initialized: true, pausable: true},
+
+	"ProposeMint":           {initialized: true, orgAdmin: true, pausable: true},
+	"ApproveMintProposal":   {initialized: true, orgAdmin: true, pausable: true},
+	"ExecuteMintProposal":   {initialized: true, pausable: true},
+	"RejectMintProposal":    {initialized: true},
+	"GetMintProposal":       {initialized: true},
+	"SetMintProposalPolicy": {initialized: true, admin: true},
+
+	"FreezeAccount":   {initialized: true, admin: true},
+	"UnfreezeAccount": {initialized: true, admin: true},
+	"IsFrozen":        {initialized: true},
+	"FreezeToken":     {initialized: true, admin: true},
+	"UnfreezeToken":   {initialized: true, admin: true},
+	"IsTokenFrozen":   {initialized: true},
+
+	"SetRecoveryQuorum": {initialized: true, admin: true},
+	"ApproveRecovery":   {initialized: true, recoveryApprover: true},
+	"ForceTransfer":     {initialized: true, recovery: true},
+	"GetRecoveryRecord": {initialized: true},
+
+	"SetMintQuotas":             {initialized: true, admin: true},
+	"RemainingSupply":           {initialized: true},
+	"CollectionSetMaxSupply":    {initialized: true, collectionScoped: true, admin: true},
+	"CollectionRemainingSupply": {initialized: true, collectionScoped: true},
+
+	"SetMintAllowlistRoot":  {initialized: true, admin: true},
+	"SetMintAllowlistQuota": {initialized: true, admin: true},
+	"GetMintAllowlist":      {initialized: true},
+	"AllowlistMintsOf":      {initialized: true},
+	"MintAllowlisted":       {initialized: true, pausable: true},
+
+	"SetTokenEndorsementPolicy": {initialized: true, admin: true},
+	"GetTokenEndorsementPolicy": {initialized: true},
+
+	"SetRecipientRegistryRequired": {initialized: true, admin: true},
+	"RegisterAccount":              {admin: true},
+	"IsRegisteredAccount":          {},
+	"RegisterClientAccount":        {admin: true},
+	"UnregisterAccount":            {admin: true},
+
+	"SetContractURI":      {initialized: true, admin: true},
+	"ContractURI":         {initialized: true},
+	"SetExtensionEnabled": {initialized: true, admin: true},
+	"SupportedExtensions": {initialized: true},
+	"SupportsInterface":   {initialized: true},
+
+	"Pause":   {initialized: true, admin: true},
+	"Unpause": {initialized: true, admin: true},
+	"Paused":  {initialized: true},
+
+	"SetStateEncoding": {initialized: true, admin: true},
+	"StateEncoding":    {initialized: true},
+
+	"SetTokenIdScheme": {initialized: true, admin: true},
+	"TokenIdScheme":    {initialized: true},
+
+	"SchemaVersion":     {initialized: true},
+	"PendingMigration":  {initialized: true, admin: true},
+	"Migrate":           {initialized: true, admin: true},
+	"CompleteMigration": {initialized: true, admin: true},
+	"ExportState":       {initialized: true},
+	"ImportState":       {admin: true},
+}
+
+// GetTransactionContextHandler makes contractapi create a TransactionContext for every transaction
+func (c *TokenERC721Contract) GetTransactionContextHandler() contractapi.SettableTransactionContextInterface {
+	return new(TransactionContext)
+}
+
+// GetBeforeTransaction registers the policy checks of transactionPolicies
+func (c *TokenERC721Contract) GetBeforeTransaction() interface{} {
+	return beforeTransaction
+}
+
+// beforeTransaction enforces the policy of the called transaction
+func beforeTransaction(ctx *TransactionContext) error {
+	function, params := _calledFunction(ctx)
+
+	policy, ok := transactionPolicies[function]
+	if !ok {
+		return unauthorizedError("transaction %s has no policy", function)
+	}
+
+	if policy.admin {
+		err := checkAdmin(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.orgAdmin {
+		err := checkOrgAdmin(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.recovery {
+		err := checkRecoveryAgent(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.recoveryApprover {
+		err := checkRecoveryRole(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if policy.initialized {
+		collectionId := LegacyCollectionID
+		if policy.collectionScoped && len(params) > 0 {
+			collectionId = params[0]
+		}
+
+		initialized, err := checkCollectionInitialized(ctx, collectionId)
+		if err != nil {
+			return err
+		}
+		if !initialized {
+			return uninitializedError(collectionId)
+		}
+	}
+
+	if policy.pausable {
+		paused, err := _isPaused(ctx)
+		if err != nil {
+			return err
+		}
+		if paused {
+			return newContractError(ErrCodePaused, "the contract is paused")
+		}
+	}
+
+	return nil
+}
+
+// _calledFunction returns the name of the called transaction without its contract namespace, and its parameters
+func _calledFunction(ctx contractapi.TransactionContextInterface) (string, []string) {
+	function, params := ctx.GetStub().GetFunctionAndParameters()
+
+	// Functions may be namespaced by the contract name
+	if separator := strings.LastIndex(function, ":"); separator >= 0 {
+		function = function[separator+1:]
+	}
+
+	// contractapi calls the method of a function name starting in lower case
+	if function != "" {
+		function = strings.ToUpper(function[:1]) + function[1:]
+	}
+
+	return function, params
+}
@@ -0,0 +1,64 @@
+package chaincode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestTransactionPolicies(t *testing.T) {
+	contract := reflect.TypeOf(new(TokenERC721Contract))
+	base := reflect.TypeOf(new(contractapi.Contract))
+
+	for i := 0; i < contract.NumMethod(); i++ {
+		name := contract.Method(i).Name
+
+		// Methods of the embedded contract and the contractapi hooks are not transactions
+		if _, ok := base.MethodByName(name); ok {
+			continue
+		}
+		if name == "GetBeforeTransaction" || name == "GetTransactionContextHandler" {
+			continue
+		}
+
+		if _, ok := transactionPolicies[name]; !ok {
+			t.Errorf("transaction %s has no entry in transactionPolicies", name)
+		}
+	}
+
+	for name := range transactionPolicies {
+		if _, ok := contract.MethodByName(name); !ok {
+			t.Errorf("transactionPolicies lists %s, which is not a transaction", name)
+		}
+	}
+}
+
+func TestBeforeTransaction(t *testing.T) {
+	ledger := newTestLedger(t)
+	admin := newTestClient(t, AdminMSPID, "admin")
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"uninitialized", admin, "TotalSupply", nil, ErrCodeNotInitialized},
+		{"lower case name", bob, "initialize", []string{"HLF721", "HLF", "0"}, ErrCodeUnauthorized},
+		{"namespaced name", bob, "TokenERC721Contract:Initialize", []string{"HLF721", "HLF", "0"}, ErrCodeUnauthorized},
+		{"no policy", admin, "Unknown", nil, ErrCodeUnauthorized},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(test.client, test.function, string(test.want), test.args...)
+		})
+	}
+
+	// Exempt transactions run before Initialize
+	ledger.ok(bob, "ClientAccountID")
+	ledger.expect(bob, "false", "IsRegisteredAccount", ledger.account(bob))
+}
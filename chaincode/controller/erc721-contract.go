@@ -2,7 +2,6 @@ package chaincode
 
 import (
 	"encoding/json"
-	"fmt"
 	"hyperledger_erc721/chaincode/model"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -12,16 +11,76 @@ import (
 const balancePrefix = "balance"
 const nftPrefix = "nft"
 const approvalPrefix = "approval"
+const fractionPrefix = "fraction"
+const sharePrefix = "share"
+const collectionPrefix = "collection"
+const accountPrefix = "account"
+const accountAliasPrefix = "alias"
+const mintProposalPrefix = "mintProposal"
+const allowlistMintPrefix = "allowlistMint"
+const mintCountPrefix = "mintCount"
+const frozenAccountPrefix = "frozenAccount"
+const frozenTokenPrefix = "frozenToken"
+const recoveryPrefix = "recovery"
+const recoveryApprovalPrefix = "recoveryApproval"
+const activityPrefix = "activity"
+const tokenIdCounterPrefix = "tokenIdCounter"
+const requestPrefix = "request"
 
 // SetEvent() key
 const (
 	TransferEventKey       = "Transfer"
 	ApprovalForAllEventKey = "ApprovalForAll"
+	ShareTransferEventKey  = "ShareTransfer"
+	MetadataUpdateEventKey = "MetadataUpdate"
+
+	FractionalizedEventKey = "Fractionalized"
+	RedeemedEventKey       = "Redeemed"
+
+	ApprovalsRevokedEventKey = "ApprovalsRevoked"
+
+	MintProposedEventKey         = "MintProposed"
+	MintProposalApprovedEventKey = "MintProposalApproved"
+	MintProposalExecutedEventKey = "MintProposalExecuted"
+	MintProposalRejectedEventKey = "MintProposalRejected"
+
+	ComplianceEventKey = "Compliance"
+
+	RecoveryApprovedEventKey = "RecoveryApproved"
+	ForcedTransferEventKey   = "ForcedTransfer"
+)
+
+// Address used as the sender of mint events and the recipient of burn events, never as a token owner
+const ZeroAddress = "0x0"
+
+// Address that holds fractionalized tokens while their shares are in circulation
+const FractionVaultAddress = "fraction-vault"
+
+// MSP of the issuer with privilege to administer the contract
+const AdminMSPID = "Org1MSP"
+
+// Certificate attribute and value, issued by the CA of AdminMSPID, of the agents allowed to force transfers
+const (
+	RecoveryRoleAttribute = "role"
+	RecoveryRole          = "recovery"
 )
 
+// Certificate OU the Fabric CA issues to organization admins when NodeOUs are enabled
+const OrgAdminOU = "admin"
+
 // Define key names for options
 const InitialKey = "initial"
 
+// Key written by the first mint, after which state can no longer be imported
+const MintedKey = "minted"
+
+// Key written by the first import, so the following pages can add tokens next to the imported ones
+const ImportedKey = "imported"
+
+// Collection used by every method that is not collection-aware.
+// Its metadata lives under InitialKey and its keys carry no collection attribute.
+const LegacyCollectionID = ""
+
 // TokenERC721Contract contract for managing CRUD operations
 type TokenERC721Contract struct {
 	contractapi.Contract
@@ -31,49 +90,47 @@ type TokenERC721Contract struct {
 //
 // param {String} name The name of the token
 // param {String} symbol The symbol of the token
+// param {Number} maxSupply The number of tokens that can ever be minted, 0 for no limit
 /*
 `Initialize` is set information for a token and intialize contract.
 */
-func (c *TokenERC721Contract) Initialize(ctx contractapi.TransactionContextInterface, name string, symbol string) (bool, error) {
-	// Check minter authorization - this sample assumes Org1 is the issuer with privilege to set the name and symbol
-	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
-	if err != nil {
-		return false, fmt.Errorf("failed to get clientMSPID: %v", err)
-	}
-	if clientMSPID != "Org1MSP" {
-		return false, fmt.Errorf("client is not authorized to set the name and symbol of the token")
+func (c *TokenERC721Contract) Initialize(ctx contractapi.TransactionContextInterface, name string, symbol string, maxSupply int) (bool, error) {
+	if maxSupply < 0 {
+		return false, invalidArgumentError("maxSupply must not be negative")
 	}
 
-	bytes, err := ctx.GetStub().GetState(InitialKey)
+	bytes, err := _repository(ctx).getState(InitialKey)
 	if err != nil {
-		return false, fmt.Errorf("failed to get Metadata: %v", err)
+		return false, err
 	}
 	if bytes != nil {
-		return false, fmt.Errorf("contract options are already set, client is not authorized to change them")
+		return false, newContractError(ErrCodeAlreadyExists, "contract options are already set, client is not authorized to change them")
 	}
 
 	ERC721Metadata := model.NewERC721Metadata(name, symbol)
+	ERC721Metadata.SchemaVersion = model.CurrentSchemaVersion
+	ERC721Metadata.MaxSupply = maxSupply
 
 	ERC721MetadataBytes, err := json.Marshal(ERC721Metadata)
 
 	if err != nil {
-		return false, fmt.Errorf("failed marshal name : %s, symbol : %s", name, symbol)
+		return false, internalError("failed marshal name : %s, symbol : %s", name, symbol)
 	}
 
-	err = ctx.GetStub().PutState(InitialKey, ERC721MetadataBytes)
+	err = _repository(ctx).putState(InitialKey, ERC721MetadataBytes)
 
 	if err != nil {
-		return false, fmt.Errorf("failed putstate : %v", ERC721Metadata)
+		return false, err
 	}
 
 	// err = ctx.GetStub().PutState(nameKey, []byte(name))
 	// if err != nil {
-	// 	return false, fmt.Errorf("failed to PutState nameKey %s: %v", nameKey, err)
+	// 	return false, internalError("failed to PutState nameKey %s: %v", nameKey, err)
 	// }
 
 	// err = ctx.GetStub().PutState(symbolKey, []byte(symbol))
 	// if err != nil {
-	// 	return false, fmt.Errorf("failed to PutState symbolKey %s: %v", symbolKey, err)
+	// 	return false, internalError("failed to PutState symbolKey %s: %v", symbolKey, err)
 	// }
 
 	return true, nil
@@ -84,28 +141,18 @@ func (c *TokenERC721Contract) Initialize(ctx contractapi.TransactionContextInter
 */
 func (c *TokenERC721Contract) Name(ctx contractapi.TransactionContextInterface) (string, error) {
 
-	initialized, err := checkInitialized(ctx)
+	ERC721MetadataBytes, err := _repository(ctx).getState(InitialKey)
 
 	if err != nil {
 		return "", err
 	}
 
-	if !initialized {
-		return "", fmt.Errorf("initialized first")
-	}
-
-	ERC721MetadataBytes, err := ctx.GetStub().GetState(InitialKey)
-
-	if err != nil {
-		return "", fmt.Errorf("failed found name")
-	}
-
 	ERC721Metadata := model.NewERC721Metadata("", "")
 
 	err = json.Unmarshal(ERC721MetadataBytes, ERC721Metadata)
 
 	if err != nil {
-		return "", fmt.Errorf("failed unmarshal")
+		return "", internalError("failed unmarshal")
 	}
 
 	return *ERC721Metadata.GetName(), nil
@@ -116,54 +163,123 @@ func (c *TokenERC721Contract) Name(ctx contractapi.TransactionContextInterface)
 */
 func (c *TokenERC721Contract) Symbol(ctx contractapi.TransactionContextInterface) (string, error) {
 
-	initialized, err := checkInitialized(ctx)
+	ERC721MetadataBytes, err := _repository(ctx).getState(InitialKey)
 
 	if err != nil {
 		return "", err
 	}
 
-	if !initialized {
-		return "", fmt.Errorf("initialized first")
-	}
+	ERC721Metadata := model.NewERC721Metadata("", "")
 
-	ERC721MetadataBytes, err := ctx.GetStub().GetState(InitialKey)
+	err = json.Unmarshal(ERC721MetadataBytes, ERC721Metadata)
 
 	if err != nil {
-		return "", fmt.Errorf("failed found symbol")
+		return "", internalError("failed unmarshal")
 	}
 
-	ERC721Metadata := model.NewERC721Metadata("", "")
+	return *ERC721Metadata.GetSymbol(), nil
+}
 
-	err = json.Unmarshal(ERC721MetadataBytes, ERC721Metadata)
+/*
+Checks that contract options have been already initialized
+*/
+func checkInitialized(ctx contractapi.TransactionContextInterface) (bool, error) {
+	ERC721MetadataBytes, err := _repository(ctx).getState(InitialKey)
+
+	if err != nil {
+		return false, err
+	}
+	if ERC721MetadataBytes == nil {
+		return false, err
+	}
+	return true, nil
+}
 
+/*
+Checks that the client belongs to the admin MSP
+*/
+func checkAdmin(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return "", fmt.Errorf("failed unmarshal")
+		return internalError("failed to get clientMSPID: %v", err)
+	}
+	if clientMSPID != AdminMSPID {
+		return unauthorizedError("client of %s is not authorized to administer the contract", clientMSPID)
 	}
+	return nil
+}
 
-	return *ERC721Metadata.GetSymbol(), nil
+/*
+Checks that the client is a recovery agent of the admin MSP
+*/
+func checkRecoveryAgent(ctx contractapi.TransactionContextInterface) error {
+	err := checkAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkRecoveryRole(ctx)
 }
 
-func (c *TokenERC721Contract) Test(ctx contractapi.TransactionContextInterface) (string, error) {
-	// x509::CN=org1admin,OU=admin,O=Hyperledger,ST=North Carolina,C=US::CN=fabric-ca-server,OU=Fabric,O=Hyperledger,ST=North Carolina,C=US
-	ctx.GetClientIdentity().GetMSPID()
-	ctx.GetClientIdentity().GetX509Certificate()
+/*
+Checks that the CA of the client's organization issued its certificate with the recovery role
+*/
+func checkRecoveryRole(ctx contractapi.TransactionContextInterface) error {
+	err := ctx.GetClientIdentity().AssertAttributeValue(RecoveryRoleAttribute, RecoveryRole)
+	if err != nil {
+		return unauthorizedError("client is not a recovery agent: %v", err)
+	}
+	return nil
+}
 
-	fmt.Println(ctx.GetStub().GetChannelID())
+/*
+Checks that the client is an admin of its own organization, as marked by the OrgAdminOU of its certificate
+*/
+func checkOrgAdmin(ctx contractapi.TransactionContextInterface) error {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return internalError("failed to get client certificate: %v", err)
+	}
+	if cert == nil {
+		return unauthorizedError("client has no X.509 certificate")
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == OrgAdminOU {
+			return nil
+		}
+	}
+
+	return unauthorizedError("client is not an admin of its organization")
+}
+
+// _txTimestamp returns the transaction timestamp in Unix seconds, the same on every endorsing peer
+func _txTimestamp(ctx contractapi.TransactionContextInterface) (int64, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, internalError("failed to GetTxTimestamp: %v", err)
+	}
 
-	return "", nil
+	return timestamp.GetSeconds(), nil
 }
 
 /*
-Checks that contract options have been already initialized
+Checks that the legacy contract options or the given collection have been already initialized
 */
-func checkInitialized(ctx contractapi.TransactionContextInterface) (bool, error) {
-	ERC721MetadataBytes, err := ctx.GetStub().GetState(InitialKey)
+func checkCollectionInitialized(ctx contractapi.TransactionContextInterface, collectionId string) (bool, error) {
+	if collectionId == LegacyCollectionID {
+		return checkInitialized(ctx)
+	}
 
+	metadataKey, err := ctx.GetStub().CreateCompositeKey(collectionPrefix, []string{collectionId})
 	if err != nil {
-		return false, fmt.Errorf("failed to get metadata: %v", err)
+		return false, internalError("failed to CreateCompositeKey metadataKey: %v", err)
 	}
-	if ERC721MetadataBytes == nil {
+
+	collectionBytes, err := _repository(ctx).getState(metadataKey)
+	if err != nil {
 		return false, err
 	}
-	return true, nil
+
+	return collectionBytes != nil, nil
 }
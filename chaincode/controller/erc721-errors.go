@@ -0,0 +1,83 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ErrorCode is a stable identifier clients can branch on, unlike the error message
+type ErrorCode string
+
+// Error codes returned by the contract
+const (
+	ErrCodeNotInitialized     ErrorCode = "NOT_INITIALIZED"
+	ErrCodeCollectionNotFound ErrorCode = "COLLECTION_NOT_FOUND"
+	ErrCodeTokenNotFound      ErrorCode = "TOKEN_NOT_FOUND"
+	ErrCodeProposalNotFound   ErrorCode = "PROPOSAL_NOT_FOUND"
+	ErrCodeRecoveryNotFound   ErrorCode = "RECOVERY_NOT_FOUND"
+	ErrCodeRequestNotFound    ErrorCode = "REQUEST_NOT_FOUND"
+	ErrCodeUnauthorized       ErrorCode = "UNAUTHORIZED"
+	ErrCodeAlreadyMinted      ErrorCode = "ALREADY_MINTED"
+	ErrCodeAlreadyExists      ErrorCode = "ALREADY_EXISTS"
+	ErrCodePaused             ErrorCode = "PAUSED"
+	ErrCodeMintLimitReached   ErrorCode = "MINT_LIMIT_REACHED"
+	ErrCodeFrozen             ErrorCode = "FROZEN"
+	ErrCodeSchemaViolation    ErrorCode = "SCHEMA_VIOLATION"
+	ErrCodeInvalidArgument    ErrorCode = "INVALID_ARGUMENT"
+	ErrCodeConflict           ErrorCode = "CONFLICT"
+	ErrCodeInsufficientShares ErrorCode = "INSUFFICIENT_SHARES"
+	ErrCodeInternal           ErrorCode = "INTERNAL"
+)
+
+// ContractError is the error returned by every transaction.
+// Its message is a JSON document such as {"code":"TOKEN_NOT_FOUND","message":"..."}
+// so clients can parse it out of the peer response.
+type ContractError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func (e *ContractError) Error() string {
+	errorBytes, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf(`{"code":%q,"message":%q}`, ErrCodeInternal, e.Message)
+	}
+	return string(errorBytes)
+}
+
+func newContractError(code ErrorCode, format string, args ...interface{}) *ContractError {
+	return &ContractError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// internalError reports an unexpected failure, usually of the ledger stub
+func internalError(format string, args ...interface{}) error {
+	return newContractError(ErrCodeInternal, format, args...)
+}
+
+func unauthorizedError(format string, args ...interface{}) error {
+	return newContractError(ErrCodeUnauthorized, format, args...)
+}
+
+func invalidArgumentError(format string, args ...interface{}) error {
+	return newContractError(ErrCodeInvalidArgument, format, args...)
+}
+
+func conflictError(format string, args ...interface{}) error {
+	return newContractError(ErrCodeConflict, format, args...)
+}
+
+func tokenNotFoundError(tokenId string) error {
+	return newContractError(ErrCodeTokenNotFound, "non-fungible token %s does not exist", tokenId)
+}
+
+func proposalNotFoundError(proposalId string) error {
+	return newContractError(ErrCodeProposalNotFound, "mint proposal %s does not exist", proposalId)
+}
+
+// uninitializedError reports a missing `Initialize` or `CreateCollection` for collectionId
+func uninitializedError(collectionId string) error {
+	if collectionId == LegacyCollectionID {
+		return newContractError(ErrCodeNotInitialized, "please first initialize")
+	}
+	return newContractError(ErrCodeCollectionNotFound, "collection %s does not exist", collectionId)
+}
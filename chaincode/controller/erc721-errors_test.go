@@ -0,0 +1,45 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContractErrorCodes(t *testing.T) {
+	ledger := newTestLedger(t)
+	admin := newTestClient(t, AdminMSPID, "admin")
+	bob := newTestClient(t, "Org2MSP", "bob")
+
+	ledger.fail(admin, "OwnerOf", string(ErrCodeNotInitialized), "1")
+	ledger.ok(admin, "Initialize", "HLF721", "HLF", "0")
+	ledger.ok(admin, "MintWithTokenURI", "1", "ipfs://deed/1")
+
+	tests := []struct {
+		name     string
+		client   testClient
+		function string
+		args     []string
+		want     ErrorCode
+	}{
+		{"unknown token", admin, "OwnerOf", []string{"2"}, ErrCodeTokenNotFound},
+		{"unknown collection", bob, "CollectionOwnerOf", []string{"deeds", "1"}, ErrCodeCollectionNotFound},
+		{"minted twice", admin, "MintWithTokenURI", []string{"1", "ipfs://deed/1"}, ErrCodeAlreadyMinted},
+		{"mint by non-admin", bob, "MintWithTokenURI", []string{"2", "ipfs://deed/2"}, ErrCodeUnauthorized},
+		{"burn by non-owner", bob, "Burn", []string{"1"}, ErrCodeUnauthorized},
+		{"initialized twice", admin, "Initialize", []string{"HLF721", "HLF", "0"}, ErrCodeAlreadyExists},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			message := ledger.fail(test.client, test.function, string(test.want), test.args...)
+
+			contractErr := &ContractError{}
+			if err := json.Unmarshal([]byte(message), contractErr); err != nil {
+				t.Fatalf("%s is not a ContractError: %v", message, err)
+			}
+			if contractErr.Code != test.want || contractErr.Message == "" {
+				t.Fatalf("%+v, want code %s with a message", contractErr, test.want)
+			}
+		})
+	}
+}
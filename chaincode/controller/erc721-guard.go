@@ -0,0 +1,44 @@
+package chaincode
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// PanicGuard wraps the contract chaincode so that a panic inside any transaction
+// is returned to the client as an INTERNAL ContractError instead of crashing the chaincode container
+type PanicGuard struct {
+	shim.Chaincode
+}
+
+func NewPanicGuard(chaincode shim.Chaincode) *PanicGuard {
+	return &PanicGuard{Chaincode: chaincode}
+}
+
+func (g *PanicGuard) Init(stub shim.ChaincodeStubInterface) (response pb.Response) {
+	defer recoverTransaction(stub, &response)
+
+	return g.Chaincode.Init(stub)
+}
+
+func (g *PanicGuard) Invoke(stub shim.ChaincodeStubInterface) (response pb.Response) {
+	defer recoverTransaction(stub, &response)
+
+	return g.Chaincode.Invoke(stub)
+}
+
+// recoverTransaction turns a recovered panic into an error response; the stack is only logged on the peer
+func recoverTransaction(stub shim.ChaincodeStubInterface, response *pb.Response) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	function, _ := stub.GetFunctionAndParameters()
+	fmt.Printf("recovered panic in transaction %s (%s): %v\n%s", stub.GetTxID(), function, recovered, debug.Stack())
+
+	*response = shim.Error(internalError("unexpected failure in %s: %v", function, recovered).Error())
+}
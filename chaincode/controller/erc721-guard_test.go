@@ -0,0 +1,74 @@
+package chaincode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// panickingStub fails every state read with a panic
+type panickingStub struct {
+	*testStub
+}
+
+func (s *panickingStub) GetState(key string) ([]byte, error) {
+	panic("state database unavailable")
+}
+
+func TestPanicGuard(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	guard := NewPanicGuard(ledger.chaincode)
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+	}{
+		{"query", "TotalSupply", nil},
+		{"invoke", "MintWithTokenURI", []string{"1", "ipfs://deed/1"}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.stub.args = [][]byte{[]byte(test.function)}
+			for _, arg := range test.args {
+				ledger.stub.args = append(ledger.stub.args, []byte(arg))
+			}
+
+			ledger.stub.MockTransactionStart(test.name)
+			response := guard.Invoke(&panickingStub{ledger.stub})
+			ledger.stub.MockTransactionEnd(test.name)
+
+			if response.Status != shim.ERROR {
+				t.Fatalf("status %d, want %d", response.Status, shim.ERROR)
+			}
+			if !strings.Contains(response.Message, string(ErrCodeInternal)) {
+				t.Fatalf("%s, want an %s error", response.Message, ErrCodeInternal)
+			}
+		})
+	}
+
+	ledger.expect(admin, "0", "TotalSupply")
+}
+
+func TestMalformedStateQueries(t *testing.T) {
+	ledger, admin := newInitializedLedger(t)
+	ledger.seed(nftPrefix, []string{"1"}, "not an nft")
+
+	tests := []struct {
+		name     string
+		function string
+		args     []string
+	}{
+		{"owner", "OwnerOf", []string{"1"}},
+		{"token URI", "TokenURI", []string{"1"}},
+		{"approved", "GetApproved", []string{"1"}},
+	}
+
+	for _, test := range tests {
+		ledger.run(test.name, func(t *testing.T) {
+			ledger.fail(admin, test.function, string(ErrCodeInternal), test.args...)
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package chaincode
+
+import (
+	"container/list"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Certificate extension the Fabric CA uses to carry identity attributes
+var attributesOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// testStub adds the transaction arguments, transient data and paginated queries that MockStub leaves out
+type testStub struct {
+	*shimtest.MockStub
+	args      [][]byte
+	transient map[string][]byte
+	// couchDB returns a bookmark with every page, the last one included, as CouchDB does
+	couchDB bool
+}
+
+func (s *testStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *testStub) GetStringArgs() []string {
+	args := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+func (s *testStub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	return args[0], args[1:]
+}
+
+func (s *testStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination follows the LevelDB semantics of the peer unless couchDB is set:
+// the bookmark is the first key of the next page. It is handed out encoded, like the opaque
+// bookmarks of the peer, so that a key passed as bookmark is rejected.
+func (s *testStub) GetStateByPartialCompositeKeyWithPagination(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	iterator, err := s.MockStub.GetStateByPartialCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	return s.paginate(iterator, pageSize, bookmark)
+}
+
+// GetStateByRangeWithPagination pages through simple keys like GetStateByPartialCompositeKeyWithPagination,
+// MockStub rejects composite keys as bounds as the peer does
+func (s *testStub) GetStateByRangeWithPagination(startKey string, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	iterator, err := s.MockStub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iterator.Close()
+
+	return s.paginate(iterator, pageSize, bookmark)
+}
+
+// paginate returns the page of at most pageSize entries of iterator that starts at the key of bookmark
+func (s *testStub) paginate(iterator shim.StateQueryIteratorInterface, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	startKey, err := base64.RawURLEncoding.DecodeString(bookmark)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid bookmark %s: %v", bookmark, err)
+	}
+
+	page := &kvIterator{}
+	metadata := &pb.QueryResponseMetadata{}
+
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kv.Key < string(startKey) {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			metadata.Bookmark = base64.RawURLEncoding.EncodeToString([]byte(kv.Key))
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.kvs))
+
+	if s.couchDB && metadata.Bookmark == "" {
+		metadata.Bookmark = bookmark
+		if len(page.kvs) > 0 {
+			metadata.Bookmark = base64.RawURLEncoding.EncodeToString([]byte(page.kvs[len(page.kvs)-1].Key + "\x00"))
+		}
+	}
+
+	return page, metadata, nil
+}
+
+type kvIterator struct {
+	kvs  []*queryresult.KV
+	next int
+}
+
+func (i *kvIterator) HasNext() bool {
+	return i.next < len(i.kvs)
+}
+
+func (i *kvIterator) Next() (*queryresult.KV, error) {
+	i.next++
+	return i.kvs[i.next-1], nil
+}
+
+func (i *kvIterator) Close() error {
+	return nil
+}
+
+// testClient is the MSP and the PEM certificate a transaction is submitted with
+type testClient struct {
+	mspID string
+	cert  []byte
+}
+
+func newTestClient(t *testing.T, mspID string, commonName string) testClient {
+	return newTestClientWith(t, mspID, pkix.Name{CommonName: commonName}, nil)
+}
+
+// newOrgAdmin returns a client carrying the admin OU its organization's CA issues with NodeOUs enabled
+func newOrgAdmin(t *testing.T, mspID string, commonName string) testClient {
+	return newTestClientWith(t, mspID, pkix.Name{CommonName: commonName, OrganizationalUnit: []string{OrgAdminOU}}, nil)
+}
+
+// newTestClientWith issues a self-signed certificate for subject carrying the given CA attributes
+func newTestClientWith(t *testing.T, mspID string, subject pkix.Name, attributes map[string]string) testClient {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      subject,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	if attributes != nil {
+		attributesBytes, err := json.Marshal(map[string]interface{}{"attrs": attributes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.ExtraExtensions = []pkix.Extension{{Id: attributesOID, Value: attributesBytes}}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testClient{mspID: mspID, cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// testLedger invokes the contract one transaction at a time and discards the writes of failed transactions
+type testLedger struct {
+	t         *testing.T
+	chaincode *contractapi.ContractChaincode
+	stub      *testStub
+	txCount   int
+
+	// Timestamp of the next transactions in Unix seconds, the current time when zero
+	now int64
+	// Transient data of the next transactions
+	transient map[string][]byte
+	// Events emitted by the last transaction
+	events []*pb.ChaincodeEvent
+}
+
+func newTestLedger(t *testing.T) *testLedger {
+	chaincode, err := contractapi.NewChaincode(new(TokenERC721Contract))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testLedger{
+		t:         t,
+		chaincode: chaincode,
+		stub:      &testStub{MockStub: shimtest.NewMockStub("token_erc721", chaincode)},
+	}
+}
+
+func (l *testLedger) invoke(client testClient, function string, args ...string) pb.Response {
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: client.mspID, IdBytes: client.cert})
+	if err != nil {
+		l.t.Fatal(err)
+	}
+
+	l.txCount++
+	l.stub.Creator = creator
+	l.stub.transient = l.transient
+	l.stub.args = [][]byte{[]byte(function)}
+	for _, arg := range args {
+		l.stub.args = append(l.stub.args, []byte(arg))
+	}
+
+	state, keys := l.snapshot()
+
+	l.stub.MockTransactionStart(fmt.Sprintf("tx%d", l.txCount))
+	if l.now != 0 {
+		l.stub.TxTimestamp = &timestamp.Timestamp{Seconds: l.now}
+	}
+	response := l.chaincode.Invoke(l.stub)
+	l.stub.MockTransactionEnd(l.stub.TxID)
+
+	l.events = nil
+	for len(l.stub.ChaincodeEventsChannel) > 0 {
+		l.events = append(l.events, <-l.stub.ChaincodeEventsChannel)
+	}
+
+	if response.Status != shim.OK {
+		l.stub.State, l.stub.Keys = state, keys
+	}
+
+	return response
+}
+
+func (l *testLedger) snapshot() (map[string][]byte, *list.List) {
+	state := make(map[string][]byte, len(l.stub.State))
+	for key, value := range l.stub.State {
+		state[key] = value
+	}
+
+	keys := list.New()
+	keys.PushBackList(l.stub.Keys)
+
+	return state, keys
+}
+
+// seed stores the JSON of value under a composite key, the way records written by earlier versions look on the ledger
+func (l *testLedger) seed(objectType string, attributes []string, value interface{}) {
+	l.t.Helper()
+
+	key, err := l.stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+
+	l.txCount++
+	l.stub.MockTransactionStart(fmt.Sprintf("tx%d", l.txCount))
+	err = l.stub.PutState(key, valueBytes)
+	l.stub.MockTransactionEnd(l.stub.TxID)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+}
+
+// run runs f as a subtest of the current test against the same ledger
+func (l *testLedger) run(name string, f func(t *testing.T)) {
+	parent := l.t
+	parent.Run(name, func(t *testing.T) {
+		l.t = t
+		defer func() { l.t = parent }()
+		f(t)
+	})
+}
+
+// ok submits a transaction that must succeed and returns its payload
+func (l *testLedger) ok(client testClient, function string, args ...string) string {
+	l.t.Helper()
+
+	response := l.invoke(client, function, args...)
+	if response.Status != shim.OK {
+		l.t.Fatalf("%s%q failed: %s", function, args, response.Message)
+	}
+
+	return string(response.Payload)
+}
+
+// fail submits a transaction that must fail with an error containing want and returns the error
+func (l *testLedger) fail(client testClient, function string, want string, args ...string) string {
+	l.t.Helper()
+
+	response := l.invoke(client, function, args...)
+	if response.Status == shim.OK {
+		l.t.Fatalf("%s%q succeeded with %s, want an error containing %q", function, args, response.Payload, want)
+	}
+	if !strings.Contains(response.Message, want) {
+		l.t.Fatalf("%s%q failed with %s, want an error containing %q", function, args, response.Message, want)
+	}
+
+	return response.Message
+}
+
+// expect submits a query or transaction that must succeed with the given payload
+func (l *testLedger) expect(client testClient, want string, function string, args ...string) {
+	l.t.Helper()
+
+	if got := l.ok(client, function, args...); got != want {
+		l.t.Fatalf("%s%q = %s, want %s", function, args, got, want)
+	}
+}
+
+func (l *testLedger) account(client testClient) string {
+	l.t.Helper()
+
+	return l.ok(client, "ClientAccountID")
+}
+
+// event returns the only event of the last transaction, as Fabric keeps a single event per transaction
+func (l *testLedger) event() *pb.ChaincodeEvent {
+	l.t.Helper()
+
+	if len(l.events) != 1 {
+		l.t.Fatalf("the last transaction emitted %d events, want 1", len(l.events))
+	}
+
+	return l.events[0]
+}
+
+// newInitializedLedger returns a ledger initialized by an Org1MSP admin without supply cap
+func newInitializedLedger(t *testing.T) (*testLedger, testClient) {
+	ledger := newTestLedger(t)
+	admin := newTestClient(t, AdminMSPID, "admin")
+
+	ledger.ok(admin, "Initialize", "HLF721", "HLF", "0")
+
+	return ledger, admin
+}
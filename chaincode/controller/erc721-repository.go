@@ -0,0 +1,458 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"hyperledger_erc721/chaincode/model"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// errStopScan ends a scan early without failing it
+var errStopScan = errors.New("stop scan")
+
+// Repository gives typed access to the nft, balance and approval records of a transaction.
+// The stub does not see writes made earlier in the same transaction, so every record read or written
+// through the repository is kept in a cache that later reads, and counts, are served from.
+type Repository struct {
+	stub shim.ChaincodeStubInterface
+	// cache holds the latest value of every key read or written, nil for a missing or deleted key
+	cache map[string][]byte
+	// encoding of the records written, read from the contract metadata on first use
+	encoding string
+}
+
+func NewRepository(stub shim.ChaincodeStubInterface) *Repository {
+	return &Repository{stub: stub, cache: map[string][]byte{}}
+}
+
+// _repository returns the repository of the transaction, kept on the TransactionContext
+func _repository(ctx contractapi.TransactionContextInterface) *Repository {
+	transactionContext, ok := ctx.(*TransactionContext)
+	if !ok {
+		return NewRepository(ctx.GetStub())
+	}
+
+	if transactionContext.repository == nil {
+		transactionContext.repository = NewRepository(ctx.GetStub())
+	}
+
+	return transactionContext.repository
+}
+
+// GetNFT reads a token, upgrading records written before the current schema
+func (r *Repository) GetNFT(collectionId string, tokenId string) (*model.NFT, error) {
+	nftKey, err := r.key(nftPrefix, collectionId, tokenId)
+	if err != nil {
+		return nil, err
+	}
+
+	nftBytes, err := r.getState(nftKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nftBytes) == 0 {
+		return nil, tokenNotFoundError(tokenId)
+	}
+
+	nft := model.NewNFT("", "", "", "")
+	err = model.UnmarshalNFT(nftBytes, nft)
+	if err != nil {
+		return nil, internalError("failed to Unmarshal nftBytes: %v", err)
+	}
+
+	// Records written before the current schema are upgraded here and stored in the new layout on their next write
+	nft.Upgrade(collectionId)
+
+	return nft, nil
+}
+
+func (r *Repository) NFTExists(collectionId string, tokenId string) (bool, error) {
+	nftKey, err := r.key(nftPrefix, collectionId, tokenId)
+	if err != nil {
+		return false, err
+	}
+
+	nftBytes, err := r.getState(nftKey)
+	if err != nil {
+		return false, err
+	}
+
+	return len(nftBytes) > 0, nil
+}
+
+// PutNFT stores a token under the collection it carries
+func (r *Repository) PutNFT(nft *model.NFT) error {
+	nftKey, err := r.key(nftPrefix, nft.CollectionId, nft.TokenId)
+	if err != nil {
+		return err
+	}
+
+	nftBytes, err := r.encodeNFT(nft)
+	if err != nil {
+		return err
+	}
+
+	return r.putState(nftKey, nftBytes)
+}
+
+func (r *Repository) DeleteNFT(collectionId string, tokenId string) error {
+	nftKey, err := r.key(nftPrefix, collectionId, tokenId)
+	if err != nil {
+		return err
+	}
+
+	return r.delState(nftKey)
+}
+
+// CountNFTs counts the tokens of a collection
+func (r *Repository) CountNFTs(collectionId string) (int, error) {
+	return r.countKeys(nftPrefix, collectionId)
+}
+
+// PutBalance records tokenId in the balance of owner
+func (r *Repository) PutBalance(collectionId string, owner string, tokenId string) error {
+	balanceKey, err := r.key(balancePrefix, collectionId, owner, tokenId)
+	if err != nil {
+		return err
+	}
+
+	return r.putState(balanceKey, []byte{'\u0000'})
+}
+
+// DeleteBalance removes tokenId from the balance of owner
+func (r *Repository) DeleteBalance(collectionId string, owner string, tokenId string) error {
+	balanceKey, err := r.key(balancePrefix, collectionId, owner, tokenId)
+	if err != nil {
+		return err
+	}
+
+	return r.delState(balanceKey)
+}
+
+// MoveBalance moves the balance entry of tokenId from one account to another
+func (r *Repository) MoveBalance(collectionId string, from string, to string, tokenId string) error {
+	err := r.DeleteBalance(collectionId, from, tokenId)
+	if err != nil {
+		return err
+	}
+
+	return r.PutBalance(collectionId, to, tokenId)
+}
+
+// TokenIdsOf lists the tokens of a collection assigned to owner on the ledger
+func (r *Repository) TokenIdsOf(collectionId string, owner string) ([]string, error) {
+	tokenIds := []string{}
+
+	err := r.scanKeys(balancePrefix, collectionId, []string{owner}, func(attributes []string, value []byte) error {
+		tokenIds = append(tokenIds, attributes[len(attributes)-1])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenIds, nil
+}
+
+// CountBalance counts the tokens of a collection assigned to owner
+func (r *Repository) CountBalance(collectionId string, owner string) (int, error) {
+	return r.countKeys(balancePrefix, collectionId, owner)
+}
+
+// GetApproval reads the operator approval granted by owner, nil when there is none
+func (r *Repository) GetApproval(collectionId string, owner string, operator string) (*model.Approval, error) {
+	approvalKey, err := r.key(approvalPrefix, collectionId, owner, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	approvalBytes, err := r.getState(approvalKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(approvalBytes) == 0 {
+		return nil, nil
+	}
+
+	approval := model.NewApproval("", "", false)
+	err = model.UnmarshalApproval(approvalBytes, approval)
+	if err != nil {
+		return nil, internalError("failed to Unmarshal approval: %v", err)
+	}
+
+	approval.Upgrade(collectionId)
+
+	return approval, nil
+}
+
+// PutApproval stores an operator approval under the collection it carries
+func (r *Repository) PutApproval(approval *model.Approval) error {
+	approvalKey, err := r.key(approvalPrefix, approval.CollectionId, approval.Owner, approval.Operator)
+	if err != nil {
+		return err
+	}
+
+	approvalBytes, err := r.encodeApproval(approval)
+	if err != nil {
+		return err
+	}
+
+	return r.putState(approvalKey, approvalBytes)
+}
+
+func (r *Repository) DeleteApproval(collectionId string, owner string, operator string) error {
+	approvalKey, err := r.key(approvalPrefix, collectionId, owner, operator)
+	if err != nil {
+		return err
+	}
+
+	return r.delState(approvalKey)
+}
+
+// ApprovalsOf lists the operator approvals of a collection granted by owner on the ledger
+func (r *Repository) ApprovalsOf(collectionId string, owner string) ([]*model.Approval, error) {
+	approvals := []*model.Approval{}
+
+	err := r.scanKeys(approvalPrefix, collectionId, []string{owner}, func(attributes []string, value []byte) error {
+		approval := model.NewApproval("", "", false)
+		err := model.UnmarshalApproval(value, approval)
+		if err != nil {
+			return internalError("failed to Unmarshal approval: %v", err)
+		}
+
+		approval.Upgrade(collectionId)
+		approvals = append(approvals, approval)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return approvals, nil
+}
+
+func (r *Repository) encodeNFT(nft *model.NFT) ([]byte, error) {
+	encoding, err := r.stateEncoding()
+	if err != nil {
+		return nil, err
+	}
+
+	nftBytes, err := model.MarshalNFT(nft, encoding)
+	if err != nil {
+		return nil, internalError("failed to marshal nft: %v", err)
+	}
+
+	return nftBytes, nil
+}
+
+func (r *Repository) encodeApproval(approval *model.Approval) ([]byte, error) {
+	encoding, err := r.stateEncoding()
+	if err != nil {
+		return nil, err
+	}
+
+	approvalBytes, err := model.MarshalApproval(approval, encoding)
+	if err != nil {
+		return nil, internalError("failed to marshal approvalBytes: %v", err)
+	}
+
+	return approvalBytes, nil
+}
+
+// stateEncoding returns the encoding of the deployment, records of a contract
+// that is not initialized yet are written as JSON
+func (r *Repository) stateEncoding() (string, error) {
+	if r.encoding != "" {
+		return r.encoding, nil
+	}
+
+	metadataBytes, err := r.getState(InitialKey)
+	if err != nil {
+		return "", err
+	}
+
+	metadata := model.NewERC721Metadata("", "")
+	if metadataBytes != nil {
+		err = json.Unmarshal(metadataBytes, metadata)
+		if err != nil {
+			return "", internalError("failed unmarshal")
+		}
+	}
+
+	r.encoding = metadata.StoredStateEncoding()
+
+	return r.encoding, nil
+}
+
+// key builds the composite key of objectType for a collection.
+// Keys of the legacy collection keep their original layout so existing state stays readable.
+func (r *Repository) key(objectType string, collectionId string, attributes ...string) (string, error) {
+	if collectionId != LegacyCollectionID {
+		attributes = append([]string{collectionId}, attributes...)
+	}
+
+	key, err := r.stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", internalError("failed to CreateCompositeKey %s: %v", objectType, err)
+	}
+
+	return key, nil
+}
+
+func (r *Repository) getState(key string) ([]byte, error) {
+	if value, ok := r.cache[key]; ok {
+		return value, nil
+	}
+
+	value, err := r.stub.GetState(key)
+	if err != nil {
+		return nil, internalError("failed to GetState %s: %v", key, err)
+	}
+
+	r.cache[key] = value
+
+	return value, nil
+}
+
+func (r *Repository) putState(key string, value []byte) error {
+	err := r.stub.PutState(key, value)
+	if err != nil {
+		return internalError("failed to PutState %s: %v", key, err)
+	}
+
+	r.cache[key] = value
+
+	// Records written after the metadata use the encoding it declares
+	if key == InitialKey {
+		r.encoding = ""
+	}
+
+	return nil
+}
+
+func (r *Repository) delState(key string) error {
+	err := r.stub.DelState(key)
+	if err != nil {
+		return internalError("failed to DelState %s: %v", key, err)
+	}
+
+	r.cache[key] = nil
+
+	return nil
+}
+
+// countKeys counts the keys of objectType in a collection that are exactly one attribute longer than partial,
+// as they stand after the writes of this transaction
+func (r *Repository) countKeys(objectType string, collectionId string, partial ...string) (int, error) {
+	count := 0
+
+	err := r.scanKeys(objectType, collectionId, partial, func(attributes []string, value []byte) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// scanKeys calls visit with the attributes and value of every key of objectType in a collection
+// that is exactly one attribute longer than partial, as they stand after the writes of this transaction.
+// Legacy and collection keys share object types, so longer and shorter keys are skipped.
+func (r *Repository) scanKeys(objectType string, collectionId string, partial []string, visit func(attributes []string, value []byte) error) error {
+	if collectionId != LegacyCollectionID {
+		partial = append([]string{collectionId}, partial...)
+	}
+
+	return r.scan(objectType, partial, func(key string, value []byte) error {
+		_, attributes, err := r.stub.SplitCompositeKey(key)
+		if err != nil {
+			return internalError("failed to SplitCompositeKey %s: %v", key, err)
+		}
+		if len(attributes) != len(partial)+1 {
+			return nil
+		}
+
+		return visit(attributes, value)
+	})
+}
+
+// hasKeys reports whether any key of objectType exists in any collection after the writes of this transaction
+func (r *Repository) hasKeys(objectType string) (bool, error) {
+	found := false
+
+	err := r.scanObjectType(objectType, func(key string, value []byte) error {
+		found = true
+		return errStopScan
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// scanObjectType calls visit with the key and value of every key of objectType in every collection,
+// as they stand after the writes of this transaction. visit returns errStopScan to end the scan early.
+func (r *Repository) scanObjectType(objectType string, visit func(key string, value []byte) error) error {
+	err := r.scan(objectType, []string{}, visit)
+	if err == errStopScan {
+		return nil
+	}
+
+	return err
+}
+
+// scan calls visit in key order with every key of objectType starting with the partial attributes.
+// The ledger does not return the writes of the running transaction, so keys written earlier
+// in the transaction are merged in and keys deleted earlier are skipped.
+func (r *Repository) scan(objectType string, partial []string, visit func(key string, value []byte) error) error {
+	prefix, err := r.stub.CreateCompositeKey(objectType, partial)
+	if err != nil {
+		return internalError("failed to CreateCompositeKey %s: %v", objectType, err)
+	}
+
+	iterator, err := r.stub.GetStateByPartialCompositeKey(objectType, partial)
+	if err != nil {
+		return internalError("failed to GetStateByPartialCompositeKey %s: %v", objectType, err)
+	}
+	defer iterator.Close()
+
+	values := map[string][]byte{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return internalError("failed to iterate %s: %v", objectType, err)
+		}
+		values[kv.Key] = kv.Value
+	}
+
+	for key, value := range r.cache {
+		if strings.HasPrefix(key, prefix) {
+			values[key] = value
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		if len(value) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err = visit(key, values[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
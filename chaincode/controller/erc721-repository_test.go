@@ -0,0 +1,98 @@
+package chaincode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+// pendingStub keeps the writes of a transaction out of the ledger until commit, as the peer does
+type pendingStub struct {
+	*shimtest.MockStub
+	writes map[string][]byte
+}
+
+func (s *pendingStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+func (s *pendingStub) DelState(key string) error {
+	s.writes[key] = nil
+	return nil
+}
+
+func (s *pendingStub) commit(t *testing.T) {
+	s.MockTransactionStart("commit")
+	defer s.MockTransactionEnd("commit")
+
+	for key, value := range s.writes {
+		var err error
+		if value == nil {
+			err = s.MockStub.DelState(key)
+		} else {
+			err = s.MockStub.PutState(key, value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.writes = map[string][]byte{}
+}
+
+func TestRepositoryPendingWrites(t *testing.T) {
+	stub := &pendingStub{MockStub: shimtest.NewMockStub("token_erc721", nil), writes: map[string][]byte{}}
+
+	repository := NewRepository(stub)
+	for _, tokenId := range []string{"1", "2"} {
+		if err := repository.PutBalance(LegacyCollectionID, "alice", tokenId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stub.commit(t)
+
+	// The writes of the running transaction are not on the ledger yet
+	repository = NewRepository(stub)
+	if err := repository.MoveBalance(LegacyCollectionID, "alice", "bob", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.PutBalance(LegacyCollectionID, "bob", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.PutBalance("deeds", "bob", "4"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		collectionId string
+		owner        string
+		want         []string
+	}{
+		{"deleted key", LegacyCollectionID, "alice", []string{"2"}},
+		{"written keys", LegacyCollectionID, "bob", []string{"1", "3"}},
+		{"collection keys", "deeds", "bob", []string{"4"}},
+		{"no keys", "deeds", "alice", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokenIds, err := repository.TokenIdsOf(test.collectionId, test.owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tokenIds, test.want) {
+				t.Fatalf("TokenIdsOf = %q, want %q", tokenIds, test.want)
+			}
+
+			count, err := repository.CountBalance(test.collectionId, test.owner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != len(test.want) {
+				t.Fatalf("CountBalance = %d, want %d", count, len(test.want))
+			}
+		})
+	}
+}
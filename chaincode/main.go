@@ -1,14 +1,15 @@
 package main
 
 import (
-	chaincode "hyperledger_erc721/chaincode/controller"
+	controller "hyperledger_erc721/chaincode/controller"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-contract-api-go/metadata"
 )
 
 func main() {
-	nftContract := new(chaincode.TokenERC721Contract)
+	nftContract := new(controller.TokenERC721Contract)
 	nftContract.Info.Version = "0.0.2"
 	nftContract.Info.Description = "ERC-721 fabric develop"
 	nftContract.Info.License = new(metadata.LicenseMetadata)
@@ -24,7 +25,7 @@ func main() {
 		panic("Could not create chaincode from TokenERC721Contract." + err.Error())
 	}
 
-	err = chaincode.Start()
+	err = shim.Start(controller.NewPanicGuard(chaincode))
 
 	if err != nil {
 		panic("Failed to start chaincode. " + err.Error())
@@ -0,0 +1,60 @@
+package model
+
+// Activity is an entry of the per-account activity index, written for both sides of every mint, transfer and burn.
+// From is the zero address for a mint and To for a burn. Timestamp is the transaction time in Unix seconds.
+type Activity struct {
+	Account      string `json:"account"`
+	CollectionId string `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	TokenId      string `json:"tokenId"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	TxId         string `json:"txId"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+func (a *Activity) GetAccount() *string {
+	return &a.Account
+}
+
+func (a *Activity) GetCollectionId() *string {
+	return &a.CollectionId
+}
+
+func (a *Activity) GetTokenId() *string {
+	return &a.TokenId
+}
+
+func (a *Activity) GetFrom() *string {
+	return &a.From
+}
+
+func (a *Activity) GetTo() *string {
+	return &a.To
+}
+
+func (a *Activity) GetTxId() *string {
+	return &a.TxId
+}
+
+func (a *Activity) GetTimestamp() *int64 {
+	return &a.Timestamp
+}
+
+// ActivityPage is a page of the activity of an account, oldest first.
+// Bookmark resumes the query after this page and is empty on the last one.
+type ActivityPage struct {
+	Records  []*Activity `json:"records"`
+	Bookmark string      `json:"bookmark"`
+}
+
+func NewActivityPage() *ActivityPage {
+	return &ActivityPage{Records: []*Activity{}}
+}
+
+func (p *ActivityPage) GetRecords() *[]*Activity {
+	return &p.Records
+}
+
+func (p *ActivityPage) GetBookmark() *string {
+	return &p.Bookmark
+}
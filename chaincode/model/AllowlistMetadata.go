@@ -0,0 +1,80 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// MintAllowlist lets the accounts of a Merkle tree mint for themselves during a phase.
+// Root is the hex sha256 Merkle root, Quota the number of tokens every account may mint in the phase.
+type MintAllowlist struct {
+	Root  string `json:"root"`
+	Phase string `json:"phase"`
+	Quota int    `json:"quota,omitempty" metadata:"quota,optional"`
+}
+
+// Number of tokens an allowlisted account may mint per phase unless a quota is set
+const DefaultAllowlistQuota = 1
+
+func NewMintAllowlist(root, phase string) *MintAllowlist {
+	return &MintAllowlist{Root: root, Phase: phase}
+}
+
+func (m *MintAllowlist) GetRoot() *string {
+	return &m.Root
+}
+
+func (m *MintAllowlist) GetPhase() *string {
+	return &m.Phase
+}
+
+func (m *MintAllowlist) GetQuota() *int {
+	return &m.Quota
+}
+
+// StoredQuota is the number of tokens every account may mint in the phase
+func (m *MintAllowlist) StoredQuota() int {
+	if m.Quota == 0 {
+		return DefaultAllowlistQuota
+	}
+	return m.Quota
+}
+
+// The allowlist tree hashes leaves as sha256(0x00 || account) and inner nodes as
+// sha256(0x01 || lower child || higher child), so a proof is just the list of sibling hashes.
+// The domain bytes keep an inner node from being passed off as a leaf.
+const (
+	allowlistLeafDomain = 0x00
+	allowlistNodeDomain = 0x01
+)
+
+// AllowlistLeaf is the hash of account in the allowlist tree
+func AllowlistLeaf(account string) []byte {
+	hash := sha256.Sum256(append([]byte{allowlistLeafDomain}, account...))
+	return hash[:]
+}
+
+// AllowlistNode is the hash of the inner node above two siblings, in either order
+func AllowlistNode(a, b []byte) []byte {
+	if bytes.Compare(a, b) > 0 {
+		a, b = b, a
+	}
+
+	data := make([]byte, 0, 1+len(a)+len(b))
+	data = append(data, allowlistNodeDomain)
+	data = append(data, a...)
+	data = append(data, b...)
+
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// VerifyAllowlistProof reports whether proof leads from the leaf of account to root
+func VerifyAllowlistProof(root []byte, account string, proof [][]byte) bool {
+	node := AllowlistLeaf(account)
+	for _, sibling := range proof {
+		node = AllowlistNode(node, sibling)
+	}
+
+	return bytes.Equal(node, root)
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyAllowlistProof(t *testing.T) {
+	alice, bob, carol := AllowlistLeaf("alice"), AllowlistLeaf("bob"), AllowlistLeaf("carol")
+	aliceBob := AllowlistNode(alice, bob)
+	root := AllowlistNode(aliceBob, carol)
+
+	tests := []struct {
+		name    string
+		account string
+		proof   [][]byte
+		want    bool
+	}{
+		{"left leaf", "alice", [][]byte{bob, carol}, true},
+		{"right leaf", "bob", [][]byte{alice, carol}, true},
+		{"shallow leaf", "carol", [][]byte{aliceBob}, true},
+		{"other account", "dave", [][]byte{bob, carol}, false},
+		{"siblings out of order", "alice", [][]byte{carol, bob}, false},
+		{"missing sibling", "alice", [][]byte{bob}, false},
+		{"inner node hash as account", hex.EncodeToString(aliceBob), [][]byte{carol}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := VerifyAllowlistProof(root, test.account, test.proof); got != test.want {
+				t.Fatalf("VerifyAllowlistProof(%s) = %v, want %v", test.account, got, test.want)
+			}
+		})
+	}
+}
+
+// The root and proofs the allowlist command of the API builds for sha256("alice"), sha256("bob") and sha256("carol"),
+// see TestBuildTree of hyperledger_explorer/cmd/allowlist
+func TestVerifyAllowlistVectors(t *testing.T) {
+	root := "70329f4164f88f7136b49e54140712fed16a3943f2ab9bd316cd8c7db0ff4291"
+	proofs := map[string][]string{
+		"2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90": {"4888670d33fad94be0a2730569bb6912088e9ab3c20ddf66c9686682182a3d7c", "8ac791879a9df20949f8d71412b45b4b4d8262f38ab8e3e325ce59a184546027"},
+		"81b637d8fcd2c6da6359e6963113a1170de795e4b725b84d1e0b4cfd9ec58ce9": {"561f5067a31d8316497758b4d7131a58c31dcdd367d653a3a615783f84e94753", "8ac791879a9df20949f8d71412b45b4b4d8262f38ab8e3e325ce59a184546027"},
+		"4c26d9074c27d89ede59270c0ac14b71e071b15239519f75474b2f3ba63481f5": {"3620e4d34725376d3f2319c724bb0ffee2818b0de63b22203d5f7dcfac8e812c"},
+	}
+
+	rootHash, _ := hex.DecodeString(root)
+	for account, proof := range proofs {
+		proofHashes := make([][]byte, len(proof))
+		for index, hash := range proof {
+			proofHashes[index], _ = hex.DecodeString(hash)
+		}
+
+		if !VerifyAllowlistProof(rootHash, account, proofHashes) {
+			t.Errorf("VerifyAllowlistProof(%s) = false, want true", account)
+		}
+	}
+}
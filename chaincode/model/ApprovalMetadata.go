@@ -1,9 +1,19 @@
 package model
 
 type Approval struct {
-	Owner    string `json:"owner"`
-	Operator string `json:"operator"`
-	Approved bool   `json:"approved"`
+	CollectionId string `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	Owner        string `json:"owner"`
+	Operator     string `json:"operator"`
+	Approved     bool   `json:"approved"`
+	Version      int    `json:"version,omitempty" metadata:"version,optional"`
+
+	// ExpiresAt is the transaction timestamp in Unix seconds from which the grant no longer applies, 0 for never
+	ExpiresAt int64 `json:"expiresAt,omitempty" metadata:"expiresAt,optional"`
+	// TokenIds limits the grant to these tokens, empty for every token of the collection
+	TokenIds []string `json:"tokenIds,omitempty" metadata:"tokenIds,optional"`
+	// MaxTransfers limits how many transfers the grant authorizes, 0 for no limit, Transfers counts the ones made
+	MaxTransfers int `json:"maxTransfers,omitempty" metadata:"maxTransfers,optional"`
+	Transfers    int `json:"transfers,omitempty" metadata:"transfers,optional"`
 }
 
 func NewApproval(owner, operator string, approved bool) *Approval {
@@ -14,6 +24,10 @@ func NewApproval(owner, operator string, approved bool) *Approval {
 	}
 }
 
+func (a *Approval) GetCollectionId() *string {
+	return &a.CollectionId
+}
+
 func (a *Approval) GetOwner() *string {
 	return &a.Owner
 }
@@ -24,3 +38,66 @@ func (a *Approval) GetOperator() *string {
 func (a *Approval) GetApproved() *bool {
 	return &a.Approved
 }
+
+func (a *Approval) GetVersion() *int {
+	return &a.Version
+}
+
+func (a *Approval) GetExpiresAt() *int64 {
+	return &a.ExpiresAt
+}
+
+func (a *Approval) GetTokenIds() *[]string {
+	return &a.TokenIds
+}
+
+func (a *Approval) GetMaxTransfers() *int {
+	return &a.MaxTransfers
+}
+
+func (a *Approval) GetTransfers() *int {
+	return &a.Transfers
+}
+
+// IsActive reports whether the grant applies at the given time and has transfers left
+func (a *Approval) IsActive(now int64) bool {
+	if !a.Approved {
+		return false
+	}
+	if a.ExpiresAt != 0 && now >= a.ExpiresAt {
+		return false
+	}
+	return a.MaxTransfers == 0 || a.Transfers < a.MaxTransfers
+}
+
+// IsScoped reports whether the grant is limited to some tokens
+func (a *Approval) IsScoped() bool {
+	return len(a.TokenIds) > 0
+}
+
+// Covers reports whether the grant extends to tokenId
+func (a *Approval) Covers(tokenId string) bool {
+	if !a.IsScoped() {
+		return true
+	}
+	for _, scopedTokenId := range a.TokenIds {
+		if scopedTokenId == tokenId {
+			return true
+		}
+	}
+	return false
+}
+
+// StoredVersion is the schema version the record was written with
+func (a *Approval) StoredVersion() int {
+	return storedVersion(a.Version)
+}
+
+// Upgrade brings a record read from the collection to the current schema version
+func (a *Approval) Upgrade(collectionId string) {
+	if a.StoredVersion() < 2 {
+		// version 2 records carry the collection they belong to
+		a.CollectionId = collectionId
+		a.Version = 2
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import "testing"
+
+func TestApprovalIsActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		approval Approval
+		want     bool
+	}{
+		{"unlimited", Approval{Approved: true}, true},
+		{"revoked", Approval{Approved: false}, false},
+		{"before expiry", Approval{Approved: true, ExpiresAt: 1001}, true},
+		{"at expiry", Approval{Approved: true, ExpiresAt: 1000}, false},
+		{"transfers left", Approval{Approved: true, MaxTransfers: 2, Transfers: 1}, true},
+		{"transfers used up", Approval{Approved: true, MaxTransfers: 2, Transfers: 2}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.approval.IsActive(1000); got != test.want {
+				t.Fatalf("IsActive = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApprovalCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		tokenIds []string
+		tokenId  string
+		want     bool
+	}{
+		{"every token", nil, "7", true},
+		{"listed token", []string{"1", "7"}, "7", true},
+		{"unlisted token", []string{"1", "2"}, "7", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			approval := &Approval{Approved: true, TokenIds: test.tokenIds}
+			if got := approval.Covers(test.tokenId); got != test.want {
+				t.Fatalf("Covers(%s) = %v, want %v", test.tokenId, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// CompileMetadataSchema parses a JSON Schema for token attributes.
+// Only references within the schema are allowed, a remote $ref or a remote base URI set by $id
+// would make endorsement depend on the network.
+func CompileMetadataSchema(schema string) (*gojsonschema.Schema, error) {
+	var document interface{}
+	err := json.Unmarshal([]byte(schema), &document)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkLocalRefs(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return gojsonschema.NewSchema(gojsonschema.NewGoLoader(document))
+}
+
+// ValidateAttributes lists every violation of the schema by an attributes document, sorted, none when it is valid.
+// Empty attributes are validated as the empty object.
+func ValidateAttributes(schema *gojsonschema.Schema, attributes string) ([]string, error) {
+	if attributes == "" {
+		attributes = "{}"
+	}
+
+	var document interface{}
+	err := json.Unmarshal([]byte(attributes), &document)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := schema.Validate(gojsonschema.NewGoLoader(document))
+	if err != nil {
+		return nil, err
+	}
+
+	violations := []string{}
+	for _, resultError := range result.Errors() {
+		violations = append(violations, resultError.String())
+	}
+	sort.Strings(violations)
+
+	return violations, nil
+}
+
+// Schema keywords that reference another schema or set the base URI references are resolved against, `id` up to draft 4
+var schemaURIKeywords = map[string]bool{"$ref": true, "$id": true, "id": true}
+
+// checkLocalRefs rejects a $ref, $id or id that is not a fragment of the schema itself
+func checkLocalRefs(document interface{}) error {
+	switch value := document.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && schemaURIKeywords[key] && !strings.HasPrefix(ref, "#") {
+				return fmt.Errorf("%s %s is not local to the schema", key, ref)
+			}
+			err := checkLocalRefs(child)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			err := checkLocalRefs(child)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
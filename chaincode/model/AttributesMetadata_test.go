@@ -0,0 +1,81 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompileMetadataSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"object", `{"type":"object","properties":{"id":{"type":"string"}}}`, ""},
+		{"local ref", `{"definitions":{"color":{"type":"string"}},"properties":{"color":{"$ref":"#/definitions/color"}}}`, ""},
+		{"local id", `{"$id":"#attributes","type":"object"}`, ""},
+		{"malformed JSON", `{"type":`, "unexpected end"},
+		{"remote ref", `{"properties":{"color":{"$ref":"https://example.com/color.json"}}}`, "$ref https://example.com/color.json"},
+		{"relative ref", `{"properties":{"color":{"$ref":"color.json"}}}`, "$ref color.json"},
+		{"remote id", `{"$id":"https://example.com/attributes.json","type":"object"}`, "$id https://example.com/attributes.json"},
+		{"remote draft 4 id", `{"id":"https://example.com/attributes.json","type":"object"}`, "id https://example.com/attributes.json"},
+		{"nested remote id", `{"items":[{"$id":"https://example.com/item.json"}]}`, "$id https://example.com/item.json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := CompileMetadataSchema(test.schema)
+			if test.want == "" {
+				if err != nil {
+					t.Fatalf("CompileMetadataSchema: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("CompileMetadataSchema error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	schema, err := CompileMetadataSchema(`{
+		"type": "object",
+		"properties": {
+			"color": {"type": "string", "enum": ["red", "blue"]},
+			"level": {"type": "integer", "minimum": 1}
+		},
+		"required": ["color"]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		attributes string
+		want       []string
+	}{
+		{"valid", `{"color":"red","level":3}`, []string{}},
+		{"empty attributes", "", []string{"(root): color is required"}},
+		{"enum", `{"color":"green"}`, []string{`color: color must be one of the following: "red", "blue"`}},
+		{"every violation sorted", `{"level":0}`, []string{"(root): color is required", "level: Must be greater than or equal to 1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violations, err := ValidateAttributes(schema, test.attributes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(violations, test.want) {
+				t.Fatalf("violations %q, want %q", violations, test.want)
+			}
+		})
+	}
+
+	if _, err := ValidateAttributes(schema, `{"color":`); err == nil {
+		t.Fatal("malformed attributes validated")
+	}
+}
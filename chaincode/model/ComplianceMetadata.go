@@ -0,0 +1,57 @@
+package model
+
+// Actions recorded by a compliance event
+const (
+	FreezeAction   = "freeze"
+	UnfreezeAction = "unfreeze"
+)
+
+// ComplianceHold is a freeze of an account or of a token, stored while it is in force
+// and emitted as the compliance event of every freeze and unfreeze.
+// Admin is the account of the administrator who acted, Timestamp the transaction time in Unix seconds.
+type ComplianceHold struct {
+	Action       string `json:"action"`
+	Account      string `json:"account,omitempty" metadata:"account,optional"`
+	CollectionId string `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	TokenId      string `json:"tokenId,omitempty" metadata:"tokenId,optional"`
+	Reason       string `json:"reason"`
+	Admin        string `json:"admin"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+func NewComplianceHold(action, reason, admin string, timestamp int64) *ComplianceHold {
+	return &ComplianceHold{
+		Action:    action,
+		Reason:    reason,
+		Admin:     admin,
+		Timestamp: timestamp,
+	}
+}
+
+func (h *ComplianceHold) GetAction() *string {
+	return &h.Action
+}
+
+func (h *ComplianceHold) GetAccount() *string {
+	return &h.Account
+}
+
+func (h *ComplianceHold) GetCollectionId() *string {
+	return &h.CollectionId
+}
+
+func (h *ComplianceHold) GetTokenId() *string {
+	return &h.TokenId
+}
+
+func (h *ComplianceHold) GetReason() *string {
+	return &h.Reason
+}
+
+func (h *ComplianceHold) GetAdmin() *string {
+	return &h.Admin
+}
+
+func (h *ComplianceHold) GetTimestamp() *int64 {
+	return &h.Timestamp
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Multihash prefix of a sha2-256 digest: the function code 0x12 and the digest length 0x20
+const sha256MultihashPrefix = "1220"
+
+// NormalizeContentHash turns a sha256 digest of a metadata document into the hex multihash stored on the token.
+// It accepts `sha256:<hex digest>`, a bare hex digest and a hex sha2-256 multihash.
+func NormalizeContentHash(hash string) (string, error) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	hash = strings.TrimPrefix(hash, "sha256:")
+
+	if len(hash) == 64 {
+		hash = sha256MultihashPrefix + hash
+	}
+	if len(hash) != 68 || !strings.HasPrefix(hash, sha256MultihashPrefix) {
+		return "", errors.New("expected a sha256 digest or sha2-256 multihash in hex")
+	}
+
+	_, err := hex.DecodeString(hash)
+	if err != nil {
+		return "", errors.New("expected a sha256 digest or sha2-256 multihash in hex")
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestNormalizeContentHash(t *testing.T) {
+	const digest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	const multihash = sha256MultihashPrefix + digest
+
+	tests := []struct {
+		name    string
+		hash    string
+		want    string
+		wantErr bool
+	}{
+		{"prefixed digest", "sha256:" + digest, multihash, false},
+		{"bare digest", digest, multihash, false},
+		{"multihash", multihash, multihash, false},
+		{"upper case and spaces", "  SHA256:9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08 ", multihash, false},
+		{"empty", "", "", true},
+		{"short digest", digest[:62], "", true},
+		{"other multihash", "1320" + digest, "", true},
+		{"not hex", "sha256:" + digest[:63] + "z", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NormalizeContentHash(test.hash)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error %v, want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("NormalizeContentHash(%q) = %s, want %s", test.hash, got, test.want)
+			}
+		})
+	}
+}
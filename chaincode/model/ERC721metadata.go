@@ -1,14 +1,52 @@
 package model
 
 type ERC721Metadata struct {
-	Name   string `json:"name"`
-	Symbol string `json:"symbol"`
+	CollectionId  string   `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	Name          string   `json:"name"`
+	Symbol        string   `json:"symbol"`
+	BaseURI       string   `json:"baseURI,omitempty" metadata:"baseURI,optional"`
+	ContractURI   string   `json:"contractURI,omitempty" metadata:"contractURI,optional"`
+	Extensions    []string `json:"extensions,omitempty" metadata:"extensions,optional"`
+	SchemaVersion int      `json:"schemaVersion,omitempty" metadata:"schemaVersion,optional"`
+	StateEncoding string   `json:"stateEncoding,omitempty" metadata:"stateEncoding,optional"`
+	TokenIdScheme string   `json:"tokenIdScheme,omitempty" metadata:"tokenIdScheme,optional"`
+
+	RequireRegisteredRecipients bool `json:"requireRegisteredRecipients,omitempty" metadata:"requireRegisteredRecipients,optional"`
+	Paused                      bool `json:"paused,omitempty" metadata:"paused,optional"`
+
+	MaxSupply        int `json:"maxSupply,omitempty" metadata:"maxSupply,optional"`
+	AccountMintQuota int `json:"accountMintQuota,omitempty" metadata:"accountMintQuota,optional"`
+	MSPMintQuota     int `json:"mspMintQuota,omitempty" metadata:"mspMintQuota,optional"`
+
+	MintQuorum      int   `json:"mintQuorum,omitempty" metadata:"mintQuorum,optional"`
+	MintProposalTTL int64 `json:"mintProposalTTL,omitempty" metadata:"mintProposalTTL,optional"`
+
+	MintAllowlist *MintAllowlist `json:"mintAllowlist,omitempty" metadata:"mintAllowlist,optional"`
+
+	RecoveryQuorum int `json:"recoveryQuorum,omitempty" metadata:"recoveryQuorum,optional"`
+
+	// MetadataSchema is the JSON Schema the token attributes of the collection are validated against
+	MetadataSchema string `json:"metadataSchema,omitempty" metadata:"metadataSchema,optional"`
 }
 
+// Defaults of the mint proposal policy, two organizations within a week
+const (
+	DefaultMintQuorum      = 2
+	DefaultMintProposalTTL = 7 * 24 * 60 * 60
+)
+
 func NewERC721Metadata(name, symbol string) *ERC721Metadata {
 	return &ERC721Metadata{Name: name, Symbol: symbol}
 }
 
+func NewCollectionMetadata(collectionId, name, symbol, baseURI string) *ERC721Metadata {
+	return &ERC721Metadata{CollectionId: collectionId, Name: name, Symbol: symbol, BaseURI: baseURI, SchemaVersion: CurrentSchemaVersion}
+}
+
+func (e *ERC721Metadata) GetCollectionId() *string {
+	return &e.CollectionId
+}
+
 func (e *ERC721Metadata) GetName() *string {
 	return &e.Name
 }
@@ -16,3 +54,109 @@ func (e *ERC721Metadata) GetName() *string {
 func (e *ERC721Metadata) GetSymbol() *string {
 	return &e.Symbol
 }
+
+func (e *ERC721Metadata) GetBaseURI() *string {
+	return &e.BaseURI
+}
+
+func (e *ERC721Metadata) GetContractURI() *string {
+	return &e.ContractURI
+}
+
+func (e *ERC721Metadata) GetExtensions() *[]string {
+	return &e.Extensions
+}
+
+func (e *ERC721Metadata) GetSchemaVersion() *int {
+	return &e.SchemaVersion
+}
+
+// StoredSchemaVersion is the schema version of the records of the collection
+func (e *ERC721Metadata) StoredSchemaVersion() int {
+	return storedVersion(e.SchemaVersion)
+}
+
+func (e *ERC721Metadata) GetStateEncoding() *string {
+	return &e.StateEncoding
+}
+
+// StoredStateEncoding is the encoding new nft and approval records are written with
+func (e *ERC721Metadata) StoredStateEncoding() string {
+	if e.StateEncoding == "" {
+		return JSONEncoding
+	}
+	return e.StateEncoding
+}
+
+func (e *ERC721Metadata) GetTokenIdScheme() *string {
+	return &e.TokenIdScheme
+}
+
+// StoredTokenIdScheme is the scheme `Mint` assigns token IDs of the collection with
+func (e *ERC721Metadata) StoredTokenIdScheme() string {
+	if e.TokenIdScheme == "" {
+		return SequentialTokenIds
+	}
+	return e.TokenIdScheme
+}
+
+func (e *ERC721Metadata) GetMetadataSchema() *string {
+	return &e.MetadataSchema
+}
+
+func (e *ERC721Metadata) GetRequireRegisteredRecipients() *bool {
+	return &e.RequireRegisteredRecipients
+}
+
+func (e *ERC721Metadata) GetPaused() *bool {
+	return &e.Paused
+}
+
+func (e *ERC721Metadata) GetMaxSupply() *int {
+	return &e.MaxSupply
+}
+
+func (e *ERC721Metadata) GetAccountMintQuota() *int {
+	return &e.AccountMintQuota
+}
+
+func (e *ERC721Metadata) GetMSPMintQuota() *int {
+	return &e.MSPMintQuota
+}
+
+func (e *ERC721Metadata) GetMintQuorum() *int {
+	return &e.MintQuorum
+}
+
+// StoredMintQuorum is the number of organizations that must approve a mint proposal
+func (e *ERC721Metadata) StoredMintQuorum() int {
+	if e.MintQuorum == 0 {
+		return DefaultMintQuorum
+	}
+	return e.MintQuorum
+}
+
+func (e *ERC721Metadata) GetMintProposalTTL() *int64 {
+	return &e.MintProposalTTL
+}
+
+// StoredMintProposalTTL is the number of seconds a mint proposal stays open
+func (e *ERC721Metadata) StoredMintProposalTTL() int64 {
+	if e.MintProposalTTL == 0 {
+		return DefaultMintProposalTTL
+	}
+	return e.MintProposalTTL
+}
+
+func (e *ERC721Metadata) GetRecoveryQuorum() *int {
+	return &e.RecoveryQuorum
+}
+
+// StoredRecoveryQuorum is the number of organizations that must approve a forced transfer,
+// the organization of the recovery agent included. A single organization unless set.
+func (e *ERC721Metadata) StoredRecoveryQuorum() int {
+	if e.RecoveryQuorum == 0 {
+		return 1
+	}
+	return e.RecoveryQuorum
+}
@@ -0,0 +1,246 @@
+package model
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Encodings nft and approval records can be stored with, selected per deployment
+const (
+	JSONEncoding   = "json"
+	BinaryEncoding = "binary"
+)
+
+// First byte of a binary record, JSON records always start with '{'
+const binaryMarker = 0x01
+
+// The binary layout follows the protobuf wire format of
+//
+//	message NFT      { string collectionId = 1; string tokenId = 2; string owner = 3; string tokenURI = 4; string approved = 5; uint64 version = 6; bytes contentHash = 7; string attributes = 8; }
+//	message Approval { string collectionId = 1; string owner = 2; string operator = 3; bool approved = 4; uint64 version = 5;
+//	                   uint64 expiresAt = 6; repeated string tokenIds = 7; uint64 maxTransfers = 8; uint64 transfers = 9; }
+//
+// except that canonical account IDs are written as their 32 raw bytes under the field number plus accountFieldOffset.
+const accountFieldOffset = 16
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// IsValidEncoding reports whether records can be written with encoding
+func IsValidEncoding(encoding string) bool {
+	return encoding == JSONEncoding || encoding == BinaryEncoding
+}
+
+// IsBinaryEncoded reports whether a stored record uses the binary layout
+func IsBinaryEncoded(data []byte) bool {
+	return len(data) > 0 && data[0] == binaryMarker
+}
+
+func MarshalNFT(nft *NFT, encoding string) ([]byte, error) {
+	if encoding != BinaryEncoding {
+		return json.Marshal(nft)
+	}
+
+	w := newBinaryWriter()
+	w.string(1, nft.CollectionId)
+	w.string(2, nft.TokenId)
+	w.account(3, nft.Owner)
+	w.string(4, nft.TokenURI)
+	w.account(5, nft.Approved)
+	w.uvarint(6, uint64(nft.Version))
+	err := w.hex(7, nft.ContentHash)
+	if err != nil {
+		return nil, err
+	}
+	w.string(8, nft.Attributes)
+
+	return w.buf, nil
+}
+
+// UnmarshalNFT decodes a record written with either encoding
+func UnmarshalNFT(data []byte, nft *NFT) error {
+	if !IsBinaryEncoded(data) {
+		return json.Unmarshal(data, nft)
+	}
+
+	return readBinary(data, func(field int, value uint64, bytes []byte) {
+		switch field {
+		case 1:
+			nft.CollectionId = string(bytes)
+		case 2:
+			nft.TokenId = string(bytes)
+		case 3:
+			nft.Owner = string(bytes)
+		case 3 + accountFieldOffset:
+			nft.Owner = hex.EncodeToString(bytes)
+		case 4:
+			nft.TokenURI = string(bytes)
+		case 5:
+			nft.Approved = string(bytes)
+		case 5 + accountFieldOffset:
+			nft.Approved = hex.EncodeToString(bytes)
+		case 6:
+			nft.Version = int(value)
+		case 7:
+			nft.ContentHash = hex.EncodeToString(bytes)
+		case 8:
+			nft.Attributes = string(bytes)
+		}
+	})
+}
+
+func MarshalApproval(approval *Approval, encoding string) ([]byte, error) {
+	if encoding != BinaryEncoding {
+		return json.Marshal(approval)
+	}
+
+	approved := uint64(0)
+	if approval.Approved {
+		approved = 1
+	}
+
+	w := newBinaryWriter()
+	w.string(1, approval.CollectionId)
+	w.account(2, approval.Owner)
+	w.account(3, approval.Operator)
+	w.uvarint(4, approved)
+	w.uvarint(5, uint64(approval.Version))
+	w.uvarint(6, uint64(approval.ExpiresAt))
+	for _, tokenId := range approval.TokenIds {
+		w.string(7, tokenId)
+	}
+	w.uvarint(8, uint64(approval.MaxTransfers))
+	w.uvarint(9, uint64(approval.Transfers))
+
+	return w.buf, nil
+}
+
+// UnmarshalApproval decodes a record written with either encoding
+func UnmarshalApproval(data []byte, approval *Approval) error {
+	if !IsBinaryEncoded(data) {
+		return json.Unmarshal(data, approval)
+	}
+
+	return readBinary(data, func(field int, value uint64, bytes []byte) {
+		switch field {
+		case 1:
+			approval.CollectionId = string(bytes)
+		case 2:
+			approval.Owner = string(bytes)
+		case 2 + accountFieldOffset:
+			approval.Owner = hex.EncodeToString(bytes)
+		case 3:
+			approval.Operator = string(bytes)
+		case 3 + accountFieldOffset:
+			approval.Operator = hex.EncodeToString(bytes)
+		case 4:
+			approval.Approved = value != 0
+		case 5:
+			approval.Version = int(value)
+		case 6:
+			approval.ExpiresAt = int64(value)
+		case 7:
+			approval.TokenIds = append(approval.TokenIds, string(bytes))
+		case 8:
+			approval.MaxTransfers = int(value)
+		case 9:
+			approval.Transfers = int(value)
+		}
+	})
+}
+
+type binaryWriter struct {
+	buf []byte
+}
+
+func newBinaryWriter() *binaryWriter {
+	return &binaryWriter{buf: []byte{binaryMarker}}
+}
+
+func (w *binaryWriter) tag(field int, wireType int) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(field<<3|wireType))
+}
+
+// string writes a non-empty string field, empty fields are left out as in protobuf
+func (w *binaryWriter) string(field int, value string) {
+	if value == "" {
+		return
+	}
+	w.tag(field, wireBytes)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(value)))
+	w.buf = append(w.buf, value...)
+}
+
+// account writes canonical account IDs as raw bytes and any other account, such as a legacy X.509 ID, as a string
+func (w *binaryWriter) account(field int, value string) {
+	raw, err := hex.DecodeString(value)
+	if err != nil || len(raw) != 32 || hex.EncodeToString(raw) != value {
+		w.string(field, value)
+		return
+	}
+	w.tag(field+accountFieldOffset, wireBytes)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(raw)))
+	w.buf = append(w.buf, raw...)
+}
+
+// hex writes a non-empty hex string field as the bytes it encodes
+func (w *binaryWriter) hex(field int, value string) error {
+	if value == "" {
+		return nil
+	}
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return fmt.Errorf("field %d is not a hex string: %v", field, err)
+	}
+	w.tag(field, wireBytes)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(raw)))
+	w.buf = append(w.buf, raw...)
+	return nil
+}
+
+func (w *binaryWriter) uvarint(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+	w.tag(field, wireVarint)
+	w.buf = binary.AppendUvarint(w.buf, value)
+}
+
+// readBinary walks the fields of a binary record, unknown fields are passed on and may be ignored
+func readBinary(data []byte, field func(field int, value uint64, bytes []byte)) error {
+	data = data[1:]
+
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("malformed binary record: bad field tag")
+		}
+		data = data[n:]
+
+		switch key & 7 {
+		case wireVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("malformed binary record: bad varint")
+			}
+			data = data[n:]
+			field(int(key>>3), value, nil)
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errors.New("malformed binary record: bad length")
+			}
+			field(int(key>>3), 0, data[n:n+int(length)])
+			data = data[n+int(length):]
+		default:
+			return fmt.Errorf("malformed binary record: unsupported wire type %d", key&7)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	canonicalAccount = "062256884db575c0890fa51a7e566a44caa7495cca9fb8c24505c9e953953593"
+	legacyAccount    = "x509::CN=User1@org1.example.com,OU=client,L=San Francisco,ST=California,C=US::CN=ca.org1.example.com,O=org1.example.com,L=San Francisco,ST=California,C=US"
+)
+
+func sampleNFT(owner string) *NFT {
+	nft := NewNFT("1024", owner, "ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi/1024.json", "")
+	nft.CollectionId = "deeds"
+	nft.Version = CurrentSchemaVersion
+	return nft
+}
+
+func sampleApproval(owner string) *Approval {
+	approval := NewApproval(owner, owner, true)
+	approval.CollectionId = "deeds"
+	approval.Version = CurrentSchemaVersion
+	return approval
+}
+
+func TestNFTEncodingRoundTrip(t *testing.T) {
+	withContent := sampleNFT(canonicalAccount)
+	withContent.Approved = legacyAccount
+	withContent.ContentHash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	withContent.Attributes = `{"color":"red"}`
+
+	tests := []struct {
+		name string
+		nft  *NFT
+	}{
+		{"canonical owner", sampleNFT(canonicalAccount)},
+		{"legacy owner", sampleNFT(legacyAccount)},
+		{"uppercase hex owner", sampleNFT("062256884DB575C0890FA51A7E566A44CAA7495CCA9FB8C24505C9E953953593")},
+		{"all fields", withContent},
+		{"legacy record", NewNFT("1", legacyAccount, "", "")},
+	}
+
+	for _, test := range tests {
+		for _, encoding := range []string{JSONEncoding, BinaryEncoding} {
+			t.Run(test.name+"/"+encoding, func(t *testing.T) {
+				data, err := MarshalNFT(test.nft, encoding)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if IsBinaryEncoded(data) != (encoding == BinaryEncoding) {
+					t.Fatalf("IsBinaryEncoded = %v for %s", IsBinaryEncoded(data), encoding)
+				}
+
+				nft := NewNFT("", "", "", "")
+				err = UnmarshalNFT(data, nft)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(nft, test.nft) {
+					t.Fatalf("decoded %+v, want %+v", nft, test.nft)
+				}
+			})
+		}
+	}
+}
+
+func TestApprovalEncodingRoundTrip(t *testing.T) {
+	scoped := sampleApproval(canonicalAccount)
+	scoped.Operator = legacyAccount
+	scoped.ExpiresAt = 1700000000
+	scoped.TokenIds = []string{"1", "2"}
+	scoped.MaxTransfers = 3
+	scoped.Transfers = 1
+
+	tests := []struct {
+		name     string
+		approval *Approval
+	}{
+		{"canonical accounts", sampleApproval(canonicalAccount)},
+		{"legacy accounts", sampleApproval(legacyAccount)},
+		{"revoked", NewApproval(canonicalAccount, legacyAccount, false)},
+		{"scoped", scoped},
+	}
+
+	for _, test := range tests {
+		for _, encoding := range []string{JSONEncoding, BinaryEncoding} {
+			t.Run(test.name+"/"+encoding, func(t *testing.T) {
+				data, err := MarshalApproval(test.approval, encoding)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				approval := NewApproval("", "", false)
+				err = UnmarshalApproval(data, approval)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(approval, test.approval) {
+					t.Fatalf("decoded %+v, want %+v", approval, test.approval)
+				}
+			})
+		}
+	}
+}
+
+func TestBinaryEncodingErrors(t *testing.T) {
+	invalidHash := sampleNFT(canonicalAccount)
+	invalidHash.ContentHash = "not hex"
+
+	if _, err := MarshalNFT(invalidHash, BinaryEncoding); err == nil {
+		t.Fatal("MarshalNFT accepted a content hash that is not hex")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bad field tag", []byte{binaryMarker, 0x80}},
+		{"bad varint", []byte{binaryMarker, 6<<3 | wireVarint, 0x80}},
+		{"bad length", []byte{binaryMarker, 2<<3 | wireBytes, 5, 'a'}},
+		{"unsupported wire type", []byte{binaryMarker, 1<<3 | 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := UnmarshalNFT(test.data, NewNFT("", "", "", "")); err == nil {
+				t.Fatalf("UnmarshalNFT accepted %x", test.data)
+			}
+		})
+	}
+}
+
+// Compare the encodings with
+//
+//	go test ./model -run '^$' -bench Encoding
+func BenchmarkNFTEncoding(b *testing.B) {
+	for _, owner := range []string{canonicalAccount, legacyAccount} {
+		nft := sampleNFT(owner)
+
+		for _, encoding := range []string{JSONEncoding, BinaryEncoding} {
+			data, err := MarshalNFT(nft, encoding)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			name := encoding + "/canonical"
+			if owner == legacyAccount {
+				name = encoding + "/legacy"
+			}
+
+			b.Run(name+"/marshal", func(b *testing.B) {
+				b.ReportMetric(float64(len(data)), "bytes/record")
+				for i := 0; i < b.N; i++ {
+					MarshalNFT(nft, encoding)
+				}
+			})
+			b.Run(name+"/unmarshal", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					UnmarshalNFT(data, NewNFT("", "", "", ""))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkApprovalEncoding(b *testing.B) {
+	approval := sampleApproval(canonicalAccount)
+
+	for _, encoding := range []string{JSONEncoding, BinaryEncoding} {
+		data, err := MarshalApproval(approval, encoding)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(encoding+"/marshal", func(b *testing.B) {
+			b.ReportMetric(float64(len(data)), "bytes/record")
+			for i := 0; i < b.N; i++ {
+				MarshalApproval(approval, encoding)
+			}
+		})
+		b.Run(encoding+"/unmarshal", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				UnmarshalApproval(data, NewApproval("", "", false))
+			}
+		})
+	}
+}
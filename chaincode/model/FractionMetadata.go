@@ -0,0 +1,41 @@
+package model
+
+type Fraction struct {
+	TokenId     string `json:"tokenId"`
+	Owner       string `json:"owner"`
+	TotalShares int    `json:"totalShares"`
+}
+
+func NewFraction(tokenId, owner string, totalShares int) *Fraction {
+	return &Fraction{
+		TokenId:     tokenId,
+		Owner:       owner,
+		TotalShares: totalShares,
+	}
+}
+
+func (f *Fraction) GetTokenId() *string {
+	return &f.TokenId
+}
+
+func (f *Fraction) GetOwner() *string {
+	return &f.Owner
+}
+
+func (f *Fraction) GetTotalShares() *int {
+	return &f.TotalShares
+}
+
+// FractionEvent carries the vault transfer of the token together with the share movement,
+// as a transaction can emit a single event
+type FractionEvent struct {
+	Transfer *Transfer      `json:"transfer"`
+	Shares   *ShareTransfer `json:"shares"`
+}
+
+func NewFractionEvent(transfer *Transfer, shares *ShareTransfer) *FractionEvent {
+	return &FractionEvent{
+		Transfer: transfer,
+		Shares:   shares,
+	}
+}
@@ -0,0 +1,56 @@
+package model
+
+type Migration struct {
+	FromVersion int  `json:"fromVersion"`
+	ToVersion   int  `json:"toVersion"`
+	Scanned     int  `json:"scanned"`
+	Migrated    int  `json:"migrated"`
+	Done        bool `json:"done"`
+}
+
+func NewMigration(fromVersion, toVersion int) *Migration {
+	return &Migration{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+	}
+}
+
+func (m *Migration) GetFromVersion() *int {
+	return &m.FromVersion
+}
+
+func (m *Migration) GetToVersion() *int {
+	return &m.ToVersion
+}
+
+func (m *Migration) GetScanned() *int {
+	return &m.Scanned
+}
+
+func (m *Migration) GetMigrated() *int {
+	return &m.Migrated
+}
+
+func (m *Migration) GetDone() *bool {
+	return &m.Done
+}
+
+// MigrationPage lists the keys of records still to migrate, read from one page of the ledger
+type MigrationPage struct {
+	Keys     []string `json:"keys"`
+	Bookmark string   `json:"bookmark"`
+}
+
+func NewMigrationPage() *MigrationPage {
+	return &MigrationPage{
+		Keys: []string{},
+	}
+}
+
+func (m *MigrationPage) GetKeys() *[]string {
+	return &m.Keys
+}
+
+func (m *MigrationPage) GetBookmark() *string {
+	return &m.Bookmark
+}
@@ -0,0 +1,106 @@
+package model
+
+// Status of a mint proposal
+const (
+	MintProposalPending  = "pending"
+	MintProposalExecuted = "executed"
+	MintProposalRejected = "rejected"
+	// Reported for pending proposals past their expiry, never stored
+	MintProposalExpired = "expired"
+)
+
+// MintProposal is a mint waiting for the approval of other organizations.
+// Approvals holds the MSP IDs that approved it, starting with the proposer's.
+// CreatedAt and ExpiresAt are transaction timestamps in Unix seconds.
+type MintProposal struct {
+	ProposalId  string   `json:"proposalId"`
+	TokenId     string   `json:"tokenId"`
+	TokenURI    string   `json:"tokenURI"`
+	To          string   `json:"to"`
+	Proposer    string   `json:"proposer"`
+	ProposerMSP string   `json:"proposerMSP"`
+	Approvals   []string `json:"approvals"`
+	Status      string   `json:"status"`
+	CreatedAt   int64    `json:"createdAt"`
+	ExpiresAt   int64    `json:"expiresAt"`
+}
+
+// MintProposalExecutedEvent carries the executed proposal together with the Transfer of the mint,
+// as a transaction can emit a single event
+type MintProposalExecutedEvent struct {
+	Proposal *MintProposal `json:"proposal"`
+	Transfer *Transfer     `json:"transfer"`
+}
+
+func NewMintProposalExecutedEvent(proposal *MintProposal, transfer *Transfer) *MintProposalExecutedEvent {
+	return &MintProposalExecutedEvent{
+		Proposal: proposal,
+		Transfer: transfer,
+	}
+}
+
+func NewMintProposal(proposalId, tokenId, tokenURI, to string) *MintProposal {
+	return &MintProposal{
+		ProposalId: proposalId,
+		TokenId:    tokenId,
+		TokenURI:   tokenURI,
+		To:         to,
+		Approvals:  []string{},
+		Status:     MintProposalPending,
+	}
+}
+
+// IsApprovedBy reports whether mspID already approved the proposal
+func (m *MintProposal) IsApprovedBy(mspID string) bool {
+	for _, approval := range m.Approvals {
+		if approval == mspID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the proposal can no longer be approved or executed at the given time
+func (m *MintProposal) IsExpired(now int64) bool {
+	return m.Status == MintProposalPending && now >= m.ExpiresAt
+}
+
+func (m *MintProposal) GetProposalId() *string {
+	return &m.ProposalId
+}
+
+func (m *MintProposal) GetTokenId() *string {
+	return &m.TokenId
+}
+
+func (m *MintProposal) GetTokenURI() *string {
+	return &m.TokenURI
+}
+
+func (m *MintProposal) GetTo() *string {
+	return &m.To
+}
+
+func (m *MintProposal) GetProposer() *string {
+	return &m.Proposer
+}
+
+func (m *MintProposal) GetProposerMSP() *string {
+	return &m.ProposerMSP
+}
+
+func (m *MintProposal) GetApprovals() *[]string {
+	return &m.Approvals
+}
+
+func (m *MintProposal) GetStatus() *string {
+	return &m.Status
+}
+
+func (m *MintProposal) GetCreatedAt() *int64 {
+	return &m.CreatedAt
+}
+
+func (m *MintProposal) GetExpiresAt() *int64 {
+	return &m.ExpiresAt
+}
@@ -1,10 +1,16 @@
 package model
 
 type NFT struct {
-	TokenId  string `json:"tokenId"`
-	Owner    string `json:"owner"`
-	TokenURI string `json:"tokenURI"`
-	Approved string `json:"approved"`
+	CollectionId string `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	TokenId      string `json:"tokenId"`
+	Owner        string `json:"owner"`
+	TokenURI     string `json:"tokenURI"`
+	Approved     string `json:"approved"`
+	Version      int    `json:"version,omitempty" metadata:"version,optional"`
+	// ContentHash is the hex sha2-256 multihash of the metadata document at TokenURI, when anchored at mint
+	ContentHash string `json:"contentHash,omitempty" metadata:"contentHash,optional"`
+	// Attributes is the JSON document of the on-chain attributes, validated against the metadata schema of the collection
+	Attributes string `json:"attributes,omitempty" metadata:"attributes,optional"`
 }
 
 func NewNFT(tokenId, owner, tokenURI, approved string) *NFT {
@@ -16,6 +22,10 @@ func NewNFT(tokenId, owner, tokenURI, approved string) *NFT {
 	}
 }
 
+func (n *NFT) GetCollectionId() *string {
+	return &n.CollectionId
+}
+
 func (n *NFT) GetTokenId() *string {
 	return &n.TokenId
 }
@@ -31,3 +41,29 @@ func (n *NFT) GetTokenURI() *string {
 func (n *NFT) GetApproved() *string {
 	return &n.Approved
 }
+
+func (n *NFT) GetVersion() *int {
+	return &n.Version
+}
+
+func (n *NFT) GetContentHash() *string {
+	return &n.ContentHash
+}
+
+func (n *NFT) GetAttributes() *string {
+	return &n.Attributes
+}
+
+// StoredVersion is the schema version the record was written with
+func (n *NFT) StoredVersion() int {
+	return storedVersion(n.Version)
+}
+
+// Upgrade brings a record read from the collection to the current schema version
+func (n *NFT) Upgrade(collectionId string) {
+	if n.StoredVersion() < 2 {
+		// version 2 records carry the collection they belong to
+		n.CollectionId = collectionId
+		n.Version = 2
+	}
+}
@@ -0,0 +1,106 @@
+package model
+
+// RecoveryApproval collects the organizations that approved reassigning a token to To under a legal case.
+// It is only needed while the recovery quorum requires more than one organization.
+type RecoveryApproval struct {
+	CaseReference string   `json:"caseReference"`
+	CollectionId  string   `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	TokenId       string   `json:"tokenId"`
+	To            string   `json:"to"`
+	Approvals     []string `json:"approvals"`
+}
+
+func NewRecoveryApproval(caseReference, collectionId, tokenId, to string) *RecoveryApproval {
+	return &RecoveryApproval{
+		CaseReference: caseReference,
+		CollectionId:  collectionId,
+		TokenId:       tokenId,
+		To:            to,
+		Approvals:     []string{},
+	}
+}
+
+// IsApprovedBy reports whether mspID already approved the recovery
+func (r *RecoveryApproval) IsApprovedBy(mspID string) bool {
+	for _, approval := range r.Approvals {
+		if approval == mspID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecoveryApproval) GetCaseReference() *string {
+	return &r.CaseReference
+}
+
+func (r *RecoveryApproval) GetCollectionId() *string {
+	return &r.CollectionId
+}
+
+func (r *RecoveryApproval) GetTokenId() *string {
+	return &r.TokenId
+}
+
+func (r *RecoveryApproval) GetTo() *string {
+	return &r.To
+}
+
+func (r *RecoveryApproval) GetApprovals() *[]string {
+	return &r.Approvals
+}
+
+// RecoveryRecord is the immutable trace of a forced transfer, written once per case and token.
+// Agent is the account of the recovery agent who executed it, Timestamp the transaction time in Unix seconds.
+type RecoveryRecord struct {
+	CaseReference string   `json:"caseReference"`
+	CollectionId  string   `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	TokenId       string   `json:"tokenId"`
+	From          string   `json:"from"`
+	To            string   `json:"to"`
+	Agent         string   `json:"agent"`
+	AgentMSP      string   `json:"agentMSP"`
+	Approvals     []string `json:"approvals"`
+	TxId          string   `json:"txId"`
+	Timestamp     int64    `json:"timestamp"`
+}
+
+func (r *RecoveryRecord) GetCaseReference() *string {
+	return &r.CaseReference
+}
+
+func (r *RecoveryRecord) GetCollectionId() *string {
+	return &r.CollectionId
+}
+
+func (r *RecoveryRecord) GetTokenId() *string {
+	return &r.TokenId
+}
+
+func (r *RecoveryRecord) GetFrom() *string {
+	return &r.From
+}
+
+func (r *RecoveryRecord) GetTo() *string {
+	return &r.To
+}
+
+func (r *RecoveryRecord) GetAgent() *string {
+	return &r.Agent
+}
+
+func (r *RecoveryRecord) GetAgentMSP() *string {
+	return &r.AgentMSP
+}
+
+func (r *RecoveryRecord) GetApprovals() *[]string {
+	return &r.Approvals
+}
+
+func (r *RecoveryRecord) GetTxId() *string {
+	return &r.TxId
+}
+
+func (r *RecoveryRecord) GetTimestamp() *int64 {
+	return &r.Timestamp
+}
@@ -0,0 +1,49 @@
+package model
+
+// Status of a client request, only requests whose transaction committed are recorded
+const RequestCompleted = "completed"
+
+// RequestRecord is the dedupe record of a mint or transfer submitted with a client request ID.
+// ArgsHash is the hex sha256 of the transaction arguments, Result the JSON the transaction returned.
+type RequestRecord struct {
+	RequestId string `json:"requestId"`
+	Client    string `json:"client"`
+	Function  string `json:"function"`
+	ArgsHash  string `json:"argsHash"`
+	Status    string `json:"status"`
+	Result    string `json:"result"`
+	TxId      string `json:"txId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (r *RequestRecord) GetRequestId() *string {
+	return &r.RequestId
+}
+
+func (r *RequestRecord) GetClient() *string {
+	return &r.Client
+}
+
+func (r *RequestRecord) GetFunction() *string {
+	return &r.Function
+}
+
+func (r *RequestRecord) GetArgsHash() *string {
+	return &r.ArgsHash
+}
+
+func (r *RequestRecord) GetStatus() *string {
+	return &r.Status
+}
+
+func (r *RequestRecord) GetResult() *string {
+	return &r.Result
+}
+
+func (r *RequestRecord) GetTxId() *string {
+	return &r.TxId
+}
+
+func (r *RequestRecord) GetTimestamp() *int64 {
+	return &r.Timestamp
+}
@@ -0,0 +1,24 @@
+package model
+
+// Revocation reports the approvals `RevokeAllApprovals` cleared for an owner
+type Revocation struct {
+	Owner     string `json:"owner"`
+	Operators int    `json:"operators"`
+	Tokens    int    `json:"tokens"`
+}
+
+func NewRevocation(owner string) *Revocation {
+	return &Revocation{Owner: owner}
+}
+
+func (r *Revocation) GetOwner() *string {
+	return &r.Owner
+}
+
+func (r *Revocation) GetOperators() *int {
+	return &r.Operators
+}
+
+func (r *Revocation) GetTokens() *int {
+	return &r.Tokens
+}
@@ -0,0 +1,16 @@
+package model
+
+// Version of the nft and approval record layout written by this chaincode.
+// Records stored before versioning was introduced carry no version and are version 1.
+const (
+	LegacySchemaVersion  = 1
+	CurrentSchemaVersion = 2
+)
+
+// storedVersion maps the missing version tag of legacy records to LegacySchemaVersion
+func storedVersion(version int) int {
+	if version == 0 {
+		return LegacySchemaVersion
+	}
+	return version
+}
@@ -0,0 +1,34 @@
+package model
+
+type ShareTransfer struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	TokenId string `json:"tokenId"`
+	Amount  int    `json:"amount"`
+}
+
+func NewShareTransferMetadata(from, to, tokenId string, amount int) *ShareTransfer {
+
+	return &ShareTransfer{
+		From:    from,
+		To:      to,
+		TokenId: tokenId,
+		Amount:  amount,
+	}
+}
+
+func (s *ShareTransfer) GetFrom() *string {
+	return &s.From
+}
+
+func (s *ShareTransfer) GetTo() *string {
+	return &s.To
+}
+
+func (s *ShareTransfer) GetTokenId() *string {
+	return &s.TokenId
+}
+
+func (s *ShareTransfer) GetAmount() *int {
+	return &s.Amount
+}
@@ -0,0 +1,61 @@
+package model
+
+// StateBatch is a page of exported contract state and the input of an import.
+// Metadata is the legacy contract metadata and is only set on the first page.
+// Bookmark resumes the export after this page and is empty on the last one.
+type StateBatch struct {
+	Metadata *ERC721Metadata `json:"metadata,omitempty" metadata:"metadata,optional"`
+	Records  []*StateRecord  `json:"records"`
+	Bookmark string          `json:"bookmark"`
+}
+
+// StateRecord is a single ledger entry under the composite key objectType + attributes.
+// Value is the stored value: a JSON document such as a collection, nft or approval,
+// a marker byte for balances and registered accounts, a counter, or the canonical account of an alias.
+// ValidationParameter is the base64 key-level endorsement policy of an nft record, if one is set.
+type StateRecord struct {
+	ObjectType          string   `json:"objectType"`
+	Attributes          []string `json:"attributes"`
+	Value               string   `json:"value"`
+	ValidationParameter string   `json:"validationParameter,omitempty" metadata:"validationParameter,optional"`
+}
+
+func NewStateBatch() *StateBatch {
+	return &StateBatch{Records: []*StateRecord{}}
+}
+
+func NewStateRecord(objectType string, attributes []string, value string) *StateRecord {
+	return &StateRecord{
+		ObjectType: objectType,
+		Attributes: attributes,
+		Value:      value,
+	}
+}
+
+func (b *StateBatch) GetMetadata() *ERC721Metadata {
+	return b.Metadata
+}
+
+func (b *StateBatch) GetRecords() *[]*StateRecord {
+	return &b.Records
+}
+
+func (b *StateBatch) GetBookmark() *string {
+	return &b.Bookmark
+}
+
+func (r *StateRecord) GetObjectType() *string {
+	return &r.ObjectType
+}
+
+func (r *StateRecord) GetAttributes() *[]string {
+	return &r.Attributes
+}
+
+func (r *StateRecord) GetValue() *string {
+	return &r.Value
+}
+
+func (r *StateRecord) GetValidationParameter() *string {
+	return &r.ValidationParameter
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Schemes `Mint` assigns token IDs with, selected per collection
+const (
+	// SequentialTokenIds counts up from 1, skipping IDs already minted by hand
+	SequentialTokenIds = "sequential"
+	// TxHashTokenIds derives IDs from the transaction ID and the index of the mint within the transaction
+	TxHashTokenIds = "txhash"
+)
+
+// IsValidTokenIdScheme reports whether tokens can be assigned IDs with scheme
+func IsValidTokenIdScheme(scheme string) bool {
+	return scheme == SequentialTokenIds || scheme == TxHashTokenIds
+}
+
+// TxHashTokenId is the hex sha256 of the transaction ID, a colon and the decimal index
+func TxHashTokenId(txId string, index int) string {
+	digest := sha256.Sum256([]byte(txId + ":" + strconv.Itoa(index)))
+	return hex.EncodeToString(digest[:])
+}
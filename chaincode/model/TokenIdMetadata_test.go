@@ -0,0 +1,42 @@
+package model
+
+import "testing"
+
+func TestIsValidTokenIdScheme(t *testing.T) {
+	tests := []struct {
+		scheme string
+		want   bool
+	}{
+		{SequentialTokenIds, true},
+		{TxHashTokenIds, true},
+		{"", false},
+		{"Sequential", false},
+		{"uuid", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scheme, func(t *testing.T) {
+			if got := IsValidTokenIdScheme(test.scheme); got != test.want {
+				t.Fatalf("IsValidTokenIdScheme(%q) = %v, want %v", test.scheme, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTxHashTokenId(t *testing.T) {
+	// sha256 of "tx1:0"
+	if got, want := TxHashTokenId("tx1", 0), "96595c20ce1655dadd29c0300f9b56cf3b33e38dfba5af1fd5607be4a89ea783"; got != want {
+		t.Fatalf("TxHashTokenId(tx1, 0) = %s, want %s", got, want)
+	}
+
+	seen := map[string]bool{}
+	for _, txId := range []string{"tx1", "tx2"} {
+		for index := 0; index < 3; index++ {
+			tokenId := TxHashTokenId(txId, index)
+			if seen[tokenId] {
+				t.Fatalf("TxHashTokenId(%s, %d) = %s collides", txId, index, tokenId)
+			}
+			seen[tokenId] = true
+		}
+	}
+}
@@ -1,9 +1,10 @@
 package model
 
 type Transfer struct {
-	From    string `json:"from"`
-	To      string `json:"to"`
-	TokenId string `json:"tokenId"`
+	CollectionId string `json:"collectionId,omitempty" metadata:"collectionId,optional"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	TokenId      string `json:"tokenId"`
 }
 
 func NewTransferMetadata(from, to, tokenId string) *Transfer {
@@ -15,6 +16,10 @@ func NewTransferMetadata(from, to, tokenId string) *Transfer {
 	}
 }
 
+func (t *Transfer) GetCollectionId() *string {
+	return &t.CollectionId
+}
+
 func (t *Transfer) GetFrom() *string {
 	return &t.From
 }